@@ -0,0 +1,48 @@
+package btils
+
+import "sync/atomic"
+
+// Type-safe alternative to atomic.Value, built on atomic.Pointer. The zero value is ready to use and Load returns
+// the zero value of T until the first Store
+type Atomic[T any] struct {
+	p atomic.Pointer[T]
+}
+
+func (a *Atomic[T]) Load() T {
+	if v := a.p.Load(); v != nil {
+		return *v
+	}
+	return None[T]()
+}
+
+func (a *Atomic[T]) Store(v T) {
+	a.p.Store(&v)
+}
+
+// Stores v and returns the previous value
+func (a *Atomic[T]) Swap(v T) T {
+	if old := a.p.Swap(&v); old != nil {
+		return *old
+	}
+	return None[T]()
+}
+
+// Stores new if the current value equals old. Before the first Store the current value is the zero value of T.
+// Values are compared with ==, so like atomic.Value.CompareAndSwap this panics if T isn't comparable
+func (a *Atomic[T]) CompareAndSwap(old, new T) bool {
+	for {
+		cur := a.p.Load()
+
+		var curVal T
+		if cur != nil {
+			curVal = *cur
+		}
+		if any(curVal) != any(old) {
+			return false
+		}
+
+		if a.p.CompareAndSwap(cur, &new) {
+			return true
+		}
+	}
+}
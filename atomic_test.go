@@ -0,0 +1,60 @@
+package btils
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestAtomic(t *testing.T) {
+	var a Atomic[string]
+	if a.Load() != "" {
+		t.Fatalf("expected the zero value before any Store, got %q", a.Load())
+	}
+
+	// Comparing against the zero value works before the first Store
+	if !a.CompareAndSwap("", "Foo") || a.Load() != "Foo" {
+		t.Fatalf("expected CompareAndSwap from the zero value to succeed, got %q", a.Load())
+	}
+	if a.CompareAndSwap("Baar", "Baloo") || a.Load() != "Foo" {
+		t.Fatalf("expected CompareAndSwap with the wrong old value to fail, got %q", a.Load())
+	}
+	if old := a.Swap("Baar"); old != "Foo" || a.Load() != "Baar" {
+		t.Fatalf("expected Swap to return Foo and store Baar, got %q and %q", old, a.Load())
+	}
+}
+
+func TestAtomicConcurrent(t *testing.T) {
+	type config struct {
+		Workers int
+		Tags    []string // Not comparable, Load and Store still have to work
+	}
+
+	var a Atomic[config]
+	var counter Atomic[int]
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				a.Store(config{Workers: i, Tags: []string{"Foo"}})
+				if cfg := a.Load(); len(cfg.Tags) != 1 {
+					t.Errorf("torn read %+v", cfg)
+				}
+
+				for {
+					n := counter.Load()
+					if counter.CompareAndSwap(n, n+1) {
+						break
+					}
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if counter.Load() != 8000 {
+		t.Fatalf("expected 8000 increments, got %d", counter.Load())
+	}
+}
@@ -0,0 +1,153 @@
+package btils
+
+import (
+	"sync"
+	"time"
+)
+
+type batcherOptions[T any] struct {
+	maxCount int
+	maxBytes int
+	size     func(T) int
+	interval time.Duration
+}
+
+// Generic over the item type, so a size func that doesn't match the Batcher fails to compile
+type BatcherOption[T any] func(*batcherOptions[T])
+
+// Flush once the batch holds n items
+func WithMaxCount[T any](n int) BatcherOption[T] {
+	return func(o *batcherOptions[T]) {
+		o.maxCount = n
+	}
+}
+
+// Flush before the batch would exceed n bytes, as measured by size. A single item larger than n is flushed on its own
+func WithMaxBytes[T any](n int, size func(T) int) BatcherOption[T] {
+	return func(o *batcherOptions[T]) {
+		o.maxBytes = n
+		o.size = size
+	}
+}
+
+// Flush once d has passed since the first item of the batch was added
+func WithMaxInterval[T any](d time.Duration) BatcherOption[T] {
+	return func(o *batcherOptions[T]) {
+		o.interval = d
+	}
+}
+
+// Accumulates items and hands them to a flush callback once any of the configured limits is hit. Flushes never
+// overlap and happen in the order the batches were filled. Add blocks while a flush it triggered is running, which
+// pushes back on producers if the callback is slow. flush must not call Add itself
+type Batcher[T any] struct {
+	mu      sync.Mutex
+	flushMu sync.Mutex
+
+	flush   func([]T)
+	options batcherOptions[T]
+
+	batch []T
+	bytes int
+	// Incremented whenever the batch is handed off, so a timer armed for an older batch doesn't flush a newer one
+	gen    uint64
+	timer  *time.Timer
+	closed bool
+}
+
+// Without any options every Add flushes straight away
+func NewBatcher[T any](flush func([]T), opts ...BatcherOption[T]) *Batcher[T] {
+	b := &Batcher[T]{flush: flush}
+
+	for _, opt := range opts {
+		opt(&b.options)
+	}
+
+	return b
+}
+
+// Panics if the Batcher has been closed
+func (b *Batcher[T]) Add(in T) {
+	var n int
+	if b.options.size != nil {
+		n = b.options.size(in)
+	}
+
+	b.mu.Lock()
+	if b.options.size != nil && len(b.batch) > 0 && b.bytes+n > b.options.maxBytes {
+		b.handOff()
+		b.mu.Lock()
+	}
+
+	if b.closed {
+		b.mu.Unlock()
+		panic("btils: Add called on a closed Batcher")
+	}
+
+	if len(b.batch) == 0 && b.options.interval > 0 {
+		gen := b.gen
+		b.timer = time.AfterFunc(b.options.interval, func() {
+			b.mu.Lock()
+			if b.gen != gen || len(b.batch) == 0 {
+				b.mu.Unlock()
+				return
+			}
+			b.handOff()
+		})
+	}
+
+	b.batch = append(b.batch, in)
+	b.bytes += n
+
+	if (b.options.maxCount <= 0 && b.options.maxBytes <= 0 && b.options.interval <= 0) ||
+		(b.options.maxCount > 0 && len(b.batch) >= b.options.maxCount) ||
+		(b.options.size != nil && b.bytes >= b.options.maxBytes) {
+		b.handOff()
+		return
+	}
+
+	b.mu.Unlock()
+}
+
+// Has to be called with b.mu held, which it releases. Takes flushMu before releasing b.mu so batches are flushed in order
+func (b *Batcher[T]) handOff() {
+	batch := b.batch
+	b.batch = nil
+	b.bytes = 0
+	b.gen++
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+
+	b.flushMu.Lock()
+	b.mu.Unlock()
+	defer b.flushMu.Unlock()
+
+	b.flush(batch)
+}
+
+// Flushes whatever has been accumulated so far. Does nothing for an empty batch
+func (b *Batcher[T]) Flush() {
+	b.mu.Lock()
+	if len(b.batch) == 0 {
+		b.mu.Unlock()
+		return
+	}
+	b.handOff()
+}
+
+// Flushes the remaining items and waits for running flushes to finish. Safe to call multiple times
+func (b *Batcher[T]) Close() {
+	b.mu.Lock()
+	b.closed = true
+	if len(b.batch) > 0 {
+		b.handOff()
+		return
+	}
+
+	// Wait for a flush that's still running
+	b.flushMu.Lock()
+	b.mu.Unlock()
+	b.flushMu.Unlock()
+}
@@ -0,0 +1,84 @@
+package btils
+
+import (
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+type batchRecorder[T any] struct {
+	mu      sync.Mutex
+	batches [][]T
+}
+
+func (r *batchRecorder[T]) flush(batch []T) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.batches = append(r.batches, batch)
+}
+
+func (r *batchRecorder[T]) get() [][]T {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.batches)
+}
+
+func TestBatcherMaxCount(t *testing.T) {
+	var r batchRecorder[int]
+	b := NewBatcher(r.flush, WithMaxCount[int](3))
+
+	for i := 0; i < 7; i++ {
+		b.Add(i)
+	}
+
+	if batches := r.get(); len(batches) != 2 || !slices.Equal(batches[1], []int{3, 4, 5}) {
+		t.Fatalf("expected two full batches, got %v", batches)
+	}
+
+	b.Close()
+	b.Close()
+	if batches := r.get(); len(batches) != 3 || !slices.Equal(batches[2], []int{6}) {
+		t.Fatalf("expected Close to flush the remainder, got %v", batches)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected Add after Close to panic")
+		}
+	}()
+	b.Add(0)
+}
+
+func TestBatcherMaxBytes(t *testing.T) {
+	var r batchRecorder[string]
+	b := NewBatcher(r.flush, WithMaxBytes(10, func(s string) int { return len(s) }))
+
+	b.Add("Foo")
+	b.Add("Baar")
+	b.Add("Baloo") // Would exceed 10 bytes, so Foo and Baar are flushed first
+	b.Add("Golang...!!")
+	b.Close()
+
+	expected := [][]string{{"Foo", "Baar"}, {"Baloo"}, {"Golang...!!"}}
+	if batches := r.get(); !slices.EqualFunc(batches, expected, slices.Equal) {
+		t.Fatalf("expected %v, got %v", expected, batches)
+	}
+}
+
+func TestBatcherMaxInterval(t *testing.T) {
+	var r batchRecorder[int]
+	b := NewBatcher(r.flush, WithMaxInterval[int](20*time.Millisecond), WithMaxCount[int](100))
+	defer b.Close()
+
+	b.Add(1)
+	b.Add(2)
+	waitFor(t, func() bool { return len(r.get()) == 1 })
+
+	b.Add(3)
+	waitFor(t, func() bool { return len(r.get()) == 2 })
+
+	if batches := r.get(); !slices.Equal(batches[0], []int{1, 2}) || !slices.Equal(batches[1], []int{3}) {
+		t.Fatalf("unexpected batches %v", batches)
+	}
+}
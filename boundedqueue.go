@@ -0,0 +1,57 @@
+package btils
+
+import "context"
+
+// Goroutine-safe FIFO queue with a fixed capacity, the same one that backs 'ThreaderManager'. Push blocks while
+// it's full and Pop while it's empty, which makes it a building block for backpressure between producers and
+// consumers that don't fit a worker pool
+type BoundedQueue[T any] struct {
+	q *queue[T]
+}
+
+// A capacity below 1 is raised to 1
+func NewBoundedQueue[T any](capacity int) *BoundedQueue[T] {
+	return &BoundedQueue[T]{q: newQueue[T](capacity)}
+}
+
+// Blocks until there's room. Returns ErrQueueClosed if the queue is or gets closed, or ctx.Err() if ctx is done
+// first
+func (bq *BoundedQueue[T]) Push(ctx context.Context, in T) error {
+	err := bq.q.push(in, ctx.Done())
+	if err == errQueueCanceled {
+		return ctx.Err()
+	}
+	return err
+}
+
+// Non-blocking 'Push'. Reports false if the queue is full or closed
+func (bq *BoundedQueue[T]) TryPush(in T) bool {
+	return bq.q.tryPush(in) == nil
+}
+
+// Blocks until an item is available. Once the queue is closed, the remaining items are still handed out and ok is
+// only false once it's drained. ok is also false if ctx is done first, check ctx.Err() to tell the two apart
+func (bq *BoundedQueue[T]) Pop(ctx context.Context) (T, bool) {
+	in, err := bq.q.pop(ctx.Done())
+	return in, err == nil
+}
+
+// Non-blocking 'Pop'. Reports false if the queue is empty right now
+func (bq *BoundedQueue[T]) TryPop() (T, bool) {
+	in, err := bq.q.tryPop()
+	return in, err == nil
+}
+
+func (bq *BoundedQueue[T]) Len() int {
+	return bq.q.len()
+}
+
+func (bq *BoundedQueue[T]) Cap() int {
+	return bq.q.cap()
+}
+
+// Wakes up everyone blocked in Push (with ErrQueueClosed) and lets Pop drain the remaining items.
+// Safe to call multiple times
+func (bq *BoundedQueue[T]) Close() {
+	bq.q.close()
+}
@@ -0,0 +1,127 @@
+package btils
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBoundedQueue(t *testing.T) {
+	bq := NewBoundedQueue[int](2)
+	if bq.Cap() != 2 {
+		t.Fatalf("expected a capacity of 2, got %d", bq.Cap())
+	}
+
+	if !bq.TryPush(1) || !bq.TryPush(2) || bq.TryPush(3) {
+		t.Fatal("expected TryPush to fail only once the queue is full")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := bq.Push(ctx, 3); err != context.DeadlineExceeded {
+		t.Fatalf("expected Push on a full queue to give up, got %v", err)
+	}
+
+	if v, ok := bq.TryPop(); !ok || v != 1 {
+		t.Fatalf("expected 1, got %d, %v", v, ok)
+	}
+	if v, ok := bq.Pop(context.Background()); !ok || v != 2 {
+		t.Fatalf("expected 2, got %d, %v", v, ok)
+	}
+	if _, ok := bq.TryPop(); ok {
+		t.Fatal("expected TryPop on an empty queue to fail")
+	}
+	if _, ok := bq.Pop(ctx); ok || ctx.Err() == nil {
+		t.Fatal("expected Pop on an empty queue to give up once ctx is done")
+	}
+}
+
+func TestBoundedQueueClose(t *testing.T) {
+	bq := NewBoundedQueue[int](1)
+	bq.TryPush(1)
+
+	// Blocked on a full queue
+	pushed := make(chan error)
+	go func() {
+		pushed <- bq.Push(context.Background(), 2)
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	bq.Close()
+	bq.Close()
+
+	if err := <-pushed; err != ErrQueueClosed {
+		t.Fatalf("expected the blocked Push to fail with ErrQueueClosed, got %v", err)
+	}
+	if bq.TryPush(3) {
+		t.Fatal("expected TryPush on a closed queue to fail")
+	}
+
+	// The remaining item is still handed out
+	if v, ok := bq.Pop(context.Background()); !ok || v != 1 {
+		t.Fatalf("expected the queued item after Close, got %d, %v", v, ok)
+	}
+	if _, ok := bq.Pop(context.Background()); ok {
+		t.Fatal("expected Pop to report false once drained")
+	}
+
+	// Blocked on an empty queue
+	empty := NewBoundedQueue[int](1)
+	popped := make(chan bool)
+	go func() {
+		_, ok := empty.Pop(context.Background())
+		popped <- ok
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	empty.Close()
+	if <-popped {
+		t.Fatal("expected the blocked Pop to report false after Close")
+	}
+}
+
+func TestBoundedQueueConcurrent(t *testing.T) {
+	bq := NewBoundedQueue[int](4)
+
+	var producers sync.WaitGroup
+	for p := 0; p < 4; p++ {
+		producers.Add(1)
+		go func(p int) {
+			defer producers.Done()
+			for i := 0; i < 1000; i++ {
+				if err := bq.Push(context.Background(), p*1000+i); err != nil {
+					t.Error(err)
+				}
+			}
+		}(p)
+	}
+
+	var mu sync.Mutex
+	seen := make(map[int]bool)
+
+	var consumers sync.WaitGroup
+	for c := 0; c < 4; c++ {
+		consumers.Add(1)
+		go func() {
+			defer consumers.Done()
+			for {
+				v, ok := bq.Pop(context.Background())
+				if !ok {
+					return
+				}
+				mu.Lock()
+				seen[v] = true
+				mu.Unlock()
+			}
+		}()
+	}
+
+	producers.Wait()
+	bq.Close()
+	consumers.Wait()
+
+	if len(seen) != 4000 {
+		t.Fatalf("expected all 4000 items to be consumed exactly once, got %d", len(seen))
+	}
+}
@@ -0,0 +1,158 @@
+package btils
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+var ErrCircuitOpen = errors.New("btils: circuit breaker is open")
+
+type CircuitState int
+
+const (
+	// Calls go through, consecutive failures are counted
+	CircuitClosed CircuitState = iota
+	// Calls are rejected with ErrCircuitOpen until the cooldown has passed
+	CircuitOpen
+	// A single trial call is let through to test whether the dependency recovered
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// Stops calling a failing dependency. After threshold consecutive failures the breaker opens and rejects calls
+// with ErrCircuitOpen. Once cooldown has passed it half-opens and lets one trial call through: success closes it
+// again, failure re-opens it for another cooldown. Worker callbacks can wrap their downstream calls with it
+type CircuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+
+	state    CircuitState
+	failures int
+	openedAt time.Time
+	trial    bool // Whether the half-open trial call is currently running
+	// Bumped on every state change, so results of calls admitted in an earlier state can be told apart
+	generation uint64
+
+	now func() time.Time
+}
+
+type CircuitBreakerOption func(*CircuitBreaker)
+
+// Replaces the clock the breaker measures its cooldown with, e.g. with a fake one in tests. Defaults to time.Now
+func WithBreakerClock(now func() time.Time) CircuitBreakerOption {
+	return func(cb *CircuitBreaker) {
+		cb.now = now
+	}
+}
+
+func NewCircuitBreaker(threshold int, cooldown time.Duration, opts ...CircuitBreakerOption) *CircuitBreaker {
+	cb := &CircuitBreaker{
+		threshold: max(threshold, 1),
+		cooldown:  cooldown,
+		now:       time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(cb)
+	}
+
+	return cb
+}
+
+// Runs fn unless the breaker is open, in which case ErrCircuitOpen is returned without calling fn.
+// fn's error is returned as-is and counts as a failure, so does a panic, which is passed on afterwards.
+// Results of calls that were let through before the breaker changed its state are ignored, e.g. a slow success
+// that started while closed doesn't close a breaker that has opened in the meantime
+func (cb *CircuitBreaker) Execute(fn func() error) error {
+	generation, ok := cb.allow()
+	if !ok {
+		return ErrCircuitOpen
+	}
+
+	success := false
+	defer func() {
+		cb.record(generation, success)
+	}()
+
+	err := fn()
+	success = err == nil
+	return err
+}
+
+// Reports whether a call may go through, along with the generation it was admitted in
+func (cb *CircuitBreaker) allow() (uint64, bool) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.advance()
+
+	switch cb.state {
+	case CircuitOpen:
+		return 0, false
+	case CircuitHalfOpen:
+		if cb.trial {
+			return 0, false
+		}
+		cb.trial = true
+	}
+	return cb.generation, true
+}
+
+func (cb *CircuitBreaker) record(generation uint64, success bool) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if generation != cb.generation {
+		return // Stale
+	}
+
+	if success {
+		if cb.state == CircuitHalfOpen {
+			cb.transition(CircuitClosed)
+		}
+		cb.failures = 0
+		return
+	}
+
+	cb.failures++
+	if cb.state == CircuitHalfOpen || cb.failures >= cb.threshold {
+		cb.transition(CircuitOpen)
+		cb.openedAt = cb.now()
+	}
+}
+
+// Has to be called with cb.mu held. Moves an open breaker to half-open once the cooldown has passed
+func (cb *CircuitBreaker) advance() {
+	if cb.state == CircuitOpen && cb.now().Sub(cb.openedAt) >= cb.cooldown {
+		cb.transition(CircuitHalfOpen)
+	}
+}
+
+// Has to be called with cb.mu held
+func (cb *CircuitBreaker) transition(state CircuitState) {
+	cb.state = state
+	cb.failures = 0
+	cb.trial = false
+	cb.generation++
+}
+
+func (cb *CircuitBreaker) State() CircuitState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.advance()
+	return cb.state
+}
@@ -0,0 +1,159 @@
+package btils
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Unix(0, 0)
+	cb := NewCircuitBreaker(3, time.Minute, WithBreakerClock(func() time.Time { return now }))
+
+	errBoom := errors.New("boom")
+	fail := func() error { return errBoom }
+	succeed := func() error { return nil }
+
+	expectState := func(expected CircuitState) {
+		t.Helper()
+		if state := cb.State(); state != expected {
+			t.Fatalf("expected %s, got %s", expected, state)
+		}
+	}
+
+	// A success in between resets the consecutive failure count
+	cb.Execute(fail)
+	cb.Execute(fail)
+	cb.Execute(succeed)
+	cb.Execute(fail)
+	cb.Execute(fail)
+	expectState(CircuitClosed)
+
+	if err := cb.Execute(fail); err != errBoom {
+		t.Fatalf("expected fn's error, got %v", err)
+	}
+	expectState(CircuitOpen)
+
+	called := false
+	if err := cb.Execute(func() error { called = true; return nil }); err != ErrCircuitOpen || called {
+		t.Fatalf("expected an open breaker to short-circuit, got %v", err)
+	}
+
+	now = now.Add(time.Minute)
+	expectState(CircuitHalfOpen)
+
+	// A failing trial re-opens the breaker
+	cb.Execute(fail)
+	expectState(CircuitOpen)
+
+	now = now.Add(time.Minute)
+	expectState(CircuitHalfOpen)
+
+	if err := cb.Execute(succeed); err != nil {
+		t.Fatal(err)
+	}
+	expectState(CircuitClosed)
+}
+
+func TestCircuitBreakerSingleTrial(t *testing.T) {
+	now := time.Unix(0, 0)
+	cb := NewCircuitBreaker(1, time.Second, WithBreakerClock(func() time.Time { return now }))
+
+	cb.Execute(func() error { return errors.New("boom") })
+	now = now.Add(time.Second)
+
+	cb.Execute(func() error {
+		// While the trial runs, everything else is still rejected
+		if err := cb.Execute(func() error { return nil }); err != ErrCircuitOpen {
+			t.Errorf("expected a concurrent call during the trial to be rejected, got %v", err)
+		}
+		return nil
+	})
+
+	if cb.State() != CircuitClosed {
+		t.Fatalf("expected the successful trial to close the breaker, got %s", cb.State())
+	}
+}
+
+func TestCircuitBreakerStaleResults(t *testing.T) {
+	now := time.Unix(0, 0)
+	cb := NewCircuitBreaker(1, time.Second, WithBreakerClock(func() time.Time { return now }))
+
+	errBoom := errors.New("boom")
+
+	// A call admitted while closed only succeeds after another one tripped the breaker
+	cb.Execute(func() error {
+		cb.Execute(func() error { return errBoom })
+		return nil
+	})
+	if state := cb.State(); state != CircuitOpen {
+		t.Fatalf("expected a stale success to not close the breaker, got %s", state)
+	}
+}
+
+func TestCircuitBreakerStaleFailureDuringTrial(t *testing.T) {
+	now := time.Unix(0, 0)
+	cb := NewCircuitBreaker(2, time.Second, WithBreakerClock(func() time.Time { return now }))
+
+	errBoom := errors.New("boom")
+	fail := func() error { return errBoom }
+
+	started, release, done := make(chan struct{}), make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(done)
+		cb.Execute(func() error {
+			close(started)
+			<-release
+			return errBoom
+		})
+	}()
+	<-started
+
+	cb.Execute(fail)
+	cb.Execute(fail)
+	now = now.Add(time.Second)
+
+	cb.Execute(func() error {
+		// The call admitted while closed fails in the middle of the trial
+		close(release)
+		<-done
+
+		if err := cb.Execute(func() error { return nil }); err != ErrCircuitOpen {
+			t.Errorf("expected a stale failure to not allow a second trial, got %v", err)
+		}
+		return nil
+	})
+
+	if state := cb.State(); state != CircuitClosed {
+		t.Fatalf("expected the trial to close the breaker, got %s", state)
+	}
+}
+
+func TestCircuitBreakerPanic(t *testing.T) {
+	now := time.Unix(0, 0)
+	cb := NewCircuitBreaker(1, time.Second, WithBreakerClock(func() time.Time { return now }))
+
+	cb.Execute(func() error { return errors.New("boom") })
+	now = now.Add(time.Second)
+
+	func() {
+		defer func() {
+			if r := recover(); r != "Foo" {
+				t.Fatalf("expected the panic to be passed on, got %v", r)
+			}
+		}()
+		cb.Execute(func() error { panic("Foo") })
+	}()
+
+	if state := cb.State(); state != CircuitOpen {
+		t.Fatalf("expected a panicking trial to re-open the breaker, got %s", state)
+	}
+
+	now = now.Add(time.Second)
+	if err := cb.Execute(func() error { return nil }); err != nil {
+		t.Fatalf("expected another trial after the cooldown, got %v", err)
+	}
+	if state := cb.State(); state != CircuitClosed {
+		t.Fatalf("expected the trial to close the breaker, got %s", state)
+	}
+}
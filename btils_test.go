@@ -4,6 +4,8 @@ import (
 	"math/rand"
 	"testing"
 	"time"
+
+	"github.com/41Baloo/btils/btilstest"
 )
 
 func TestThreader(t *testing.T) {
@@ -18,10 +20,7 @@ func TestThreader(t *testing.T) {
 		tm.Feed(str)
 	}
 
-	for !tm.IsDone() {
-		time.Sleep(10 * time.Millisecond)
-		println("Waiting ...")
-	}
+	btilstest.Eventually(t, tm.IsDone, 5*time.Second)
 
 	tm.Stop()
 	println("Done.")
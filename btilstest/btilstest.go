@@ -0,0 +1,41 @@
+// Lightweight test helpers, meant to replace hand-rolled sleep loops and if-checks in tests
+package btilstest
+
+import (
+	"testing"
+	"time"
+)
+
+// How often 'Eventually' re-checks its condition
+const PollInterval = time.Millisecond
+
+// Fails the test if got and want differ
+func AssertEqual[T comparable](t testing.TB, got, want T) {
+	t.Helper()
+
+	if got != want {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+// Fails the test if err is not nil
+func AssertNoError(t testing.TB, err error) {
+	t.Helper()
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// Polls cond until it returns true, failing the test if that doesn't happen within timeout
+func Eventually(t testing.TB, cond func() bool, timeout time.Duration) {
+	t.Helper()
+
+	deadline := time.Now().Add(timeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("condition not met within %s", timeout)
+		}
+		time.Sleep(PollInterval)
+	}
+}
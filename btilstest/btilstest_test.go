@@ -0,0 +1,17 @@
+package btilstest
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestEventually(t *testing.T) {
+	var calls atomic.Int64
+	Eventually(t, func() bool {
+		return calls.Add(1) == 3
+	}, time.Second)
+
+	AssertEqual(t, calls.Load(), int64(3))
+	AssertNoError(t, nil)
+}
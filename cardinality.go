@@ -0,0 +1,116 @@
+package btils
+
+import (
+	"math"
+	"math/bits"
+	"sync"
+)
+
+// Number of bits of the hash that select a register, 2^14 registers
+const cardinalityPrecision = 14
+
+const cardinalityRegisters = 1 << cardinalityPrecision
+
+// Goroutine-safe approximate distinct count over arbitrarily large streams, using HyperLogLog with 2^14 registers.
+// It always takes 16 KiB, no matter how many items are added. The standard error of 'Estimate' is
+// 1.04/sqrt(2^14), about 0.8%, so roughly 98% of estimates are within 2% of the true count. Small counts are
+// close to exact
+type Cardinality struct {
+	mu        sync.Mutex
+	registers [cardinalityRegisters]uint8
+}
+
+func NewCardinality() *Cardinality {
+	return &Cardinality{}
+}
+
+// Adds data to the set, adding the same bytes again has no effect. data isn't retained
+func (c *Cardinality) Add(data []byte) {
+	c.add(hashBytes(data))
+}
+
+// Shorthand for Add(uid[:])
+func (c *Cardinality) AddUID(uid UID) {
+	c.Add(uid[:])
+}
+
+func (c *Cardinality) add(hash uint64) {
+	idx := hash >> (64 - cardinalityPrecision)
+	// Position of the first set bit in what's left of the hash. The sentinel bit caps it at 64 - precision + 1
+	rank := uint8(bits.LeadingZeros64(hash<<cardinalityPrecision|1<<(cardinalityPrecision-1))) + 1
+
+	c.mu.Lock()
+	c.registers[idx] = max(c.registers[idx], rank)
+	c.mu.Unlock()
+}
+
+// Approximate number of distinct items added so far. Uses Ertl's improved estimator ("New cardinality estimation
+// algorithms for HyperLogLog sketches", 2017), which stays unbiased across the whole range instead of needing
+// a switch to linear counting and empirical bias tables for small counts
+func (c *Cardinality) Estimate() uint64 {
+	const m = float64(cardinalityRegisters)
+	const q = 64 - cardinalityPrecision
+
+	// Histogram of the register values, which range from 0 to q+1
+	var counts [q + 2]int
+	c.mu.Lock()
+	for _, r := range c.registers {
+		counts[r]++
+	}
+	c.mu.Unlock()
+
+	z := m * hllTau(1-float64(counts[q+1])/m)
+	for k := q; k >= 1; k-- {
+		z = 0.5 * (z + float64(counts[k]))
+	}
+	z += m * hllSigma(float64(counts[0])/m)
+
+	return uint64(m*m/(2*math.Ln2*z) + 0.5)
+}
+
+// Corrects for the registers that are still 0, infinite if every register is
+func hllSigma(x float64) float64 {
+	if x == 1 {
+		return math.Inf(1)
+	}
+
+	y, z := 1.0, x
+	for {
+		x *= x
+		prev := z
+		z += x * y
+		y *= 2
+		if z == prev {
+			return z
+		}
+	}
+}
+
+// Corrects for the registers that hit the maximum value
+func hllTau(x float64) float64 {
+	if x == 0 || x == 1 {
+		return 0
+	}
+
+	y, z := 1.0, 1-x
+	for {
+		x = math.Sqrt(x)
+		prev := z
+		y *= 0.5
+		z -= (1 - x) * (1 - x) * y
+		if z == prev {
+			return z / 3
+		}
+	}
+}
+
+// FNV-1a followed by the splitmix64 finalizer 'mix64'. FNV alone doesn't mix its high bits well enough for
+// HyperLogLog, which takes the register from them
+func hashBytes(data []byte) uint64 {
+	h := uint64(14695981039346656037)
+	for _, b := range data {
+		h ^= uint64(b)
+		h *= 1099511628211
+	}
+	return mix64(h)
+}
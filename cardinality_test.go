@@ -0,0 +1,66 @@
+package btils
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestCardinality(t *testing.T) {
+	for _, n := range []int{1000, 10000, 40000, 100000, 1000000} {
+		c := NewCardinality()
+		for i := 0; i < n; i++ {
+			b := []byte("Baloo-" + strconv.Itoa(i))
+			c.Add(b)
+			c.Add(b)
+		}
+
+		estimate := c.Estimate()
+		if e := math.Abs(float64(estimate)-float64(n)) / float64(n); e > 0.03 {
+			t.Fatalf("expected an estimate within 3%% of %d, got %d", n, estimate)
+		}
+	}
+
+	if estimate := NewCardinality().Estimate(); estimate != 0 {
+		t.Fatalf("expected 0 for an empty set, got %d", estimate)
+	}
+
+	small := NewCardinality()
+	for _, s := range []string{"Foo", "Baar", "Baloo", "Foo"} {
+		small.Add([]byte(s))
+	}
+	if estimate := small.Estimate(); estimate != 3 {
+		t.Fatalf("expected small counts to be exact, got %d", estimate)
+	}
+}
+
+func TestCardinalityUID(t *testing.T) {
+	c := NewCardinality()
+
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 50000; i++ {
+				c.AddUID(*UIDFromString(fmt.Sprintf("%016d", i)))
+			}
+		}()
+	}
+	wg.Wait()
+
+	if estimate := c.Estimate(); math.Abs(float64(estimate)-50000)/50000 > 0.03 {
+		t.Fatalf("expected an estimate within 3%% of 50000, got %d", estimate)
+	}
+}
+
+func BenchmarkCardinalityAdd(b *testing.B) {
+	c := NewCardinality()
+	uid := Generate()
+
+	for i := 0; i < b.N; i++ {
+		c.AddUID(uid)
+	}
+}
@@ -0,0 +1,172 @@
+package btils
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Groups the items of src into batches. A batch is emitted once it holds size items or maxWait has passed since its
+// first item arrived, whichever happens first. A final partial batch is flushed and the returned channel closed once
+// src is closed
+func BatchChannel[T any](src <-chan T, size int, maxWait time.Duration) <-chan []T {
+	size = max(size, 1)
+	out := make(chan []T)
+
+	go func() {
+		defer close(out)
+
+		timer := time.NewTimer(maxWait)
+		timer.Stop()
+
+		var batch []T
+		flush := func() {
+			timer.Stop()
+			if len(batch) > 0 {
+				out <- batch
+				batch = nil
+			}
+		}
+
+		for {
+			select {
+			case in, ok := <-src:
+				if !ok {
+					flush()
+					return
+				}
+
+				if len(batch) == 0 {
+					batch = make([]T, 0, size)
+					timer.Reset(maxWait)
+				}
+
+				batch = append(batch, in)
+				if len(batch) >= size {
+					flush()
+				}
+			case <-timer.C:
+				flush()
+			}
+		}
+	}()
+
+	return out
+}
+
+// Fans all chans into a single channel. It's closed once every input is closed or ctx is done, whichever happens
+// first, and no goroutines are left behind either way. Order is only preserved per input.
+// Inputs are not drained after ctx is done, so producers must not block forever on sending
+func Merge[T any](ctx context.Context, chans ...<-chan T) <-chan T {
+	out := make(chan T)
+
+	var wg sync.WaitGroup
+	for _, ch := range chans {
+		wg.Add(1)
+		go func(ch <-chan T) {
+			defer wg.Done()
+			for {
+				select {
+				case v, ok := <-ch:
+					if !ok {
+						return
+					}
+					select {
+					case out <- v:
+					case <-ctx.Done():
+						return
+					}
+				case <-ctx.Done():
+					return
+				}
+			}
+		}(ch)
+	}
+
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+
+	return out
+}
+
+// Reads ch until it's closed and returns everything that was received
+func CollectAll[T any](ch <-chan T) []T {
+	// Whatever is buffered right now is the best size hint we get, append takes care of growing from there
+	res := make([]T, 0, max(len(ch), 8))
+	for v := range ch {
+		res = append(res, v)
+	}
+	return res
+}
+
+// Reads at most n items from ch, returning early if ch is closed
+func CollectN[T any](ch <-chan T, n int) []T {
+	res := make([]T, 0, max(min(n, len(ch)), 0))
+	for len(res) < n {
+		v, ok := <-ch
+		if !ok {
+			break
+		}
+		res = append(res, v)
+	}
+	return res
+}
+
+// Summary of the values inside a 'MovingAggregate' window
+type Aggregate[T Number] struct {
+	Min, Max T
+	Avg      float64
+	// Number of values the aggregate covers. Equal to the window size, except for the partial aggregate emitted
+	// when src closes before the window ever filled up
+	Count int
+}
+
+// Emits the min, max and average of the last window values of src, once per received value. Nothing is emitted
+// until the window has filled up. If src is closed before that, a single aggregate over the values received so far
+// is emitted instead, so short streams aren't lost. The returned channel is closed once src is
+func MovingAggregate[T Number](src <-chan T, window int) <-chan Aggregate[T] {
+	window = max(window, 1)
+	out := make(chan Aggregate[T])
+
+	go func() {
+		defer close(out)
+
+		ring := make([]T, 0, window)
+		next := 0
+		for v := range src {
+			if len(ring) < window {
+				ring = append(ring, v)
+				if len(ring) < window {
+					continue
+				}
+			} else {
+				ring[next] = v
+				next = (next + 1) % window
+			}
+
+			out <- aggregate(ring)
+		}
+
+		if len(ring) > 0 && len(ring) < window {
+			out <- aggregate(ring)
+		}
+	}()
+
+	return out
+}
+
+func aggregate[T Number](s []T) Aggregate[T] {
+	res := Aggregate[T]{Min: s[0], Max: s[0], Count: len(s)}
+
+	var sum float64
+	for _, v := range s {
+		res.Min = min(res.Min, v)
+		res.Max = max(res.Max, v)
+		sum += float64(v)
+	}
+	res.Avg = sum / float64(len(s))
+
+	return res
+}
@@ -0,0 +1,181 @@
+package btils
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestBatchChannelCount(t *testing.T) {
+	src := make(chan int)
+	out := BatchChannel(src, 3, time.Hour)
+
+	go func() {
+		for i := 0; i < 6; i++ {
+			src <- i
+		}
+	}()
+
+	for i := 0; i < 2; i++ {
+		if batch := <-out; len(batch) != 3 || batch[0] != i*3 {
+			t.Fatalf("unexpected batch %v", batch)
+		}
+	}
+	close(src)
+
+	if _, ok := <-out; ok {
+		t.Fatal("expected the output to be closed without an empty batch")
+	}
+}
+
+func TestBatchChannelTime(t *testing.T) {
+	src := make(chan int)
+	defer close(src)
+	out := BatchChannel(src, 100, 20*time.Millisecond)
+
+	src <- 1
+	src <- 2
+
+	select {
+	case batch := <-out:
+		if len(batch) != 2 {
+			t.Fatalf("unexpected batch %v", batch)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the timer to flush the partial batch")
+	}
+}
+
+func TestBatchChannelClose(t *testing.T) {
+	src := make(chan int, 2)
+	src <- 1
+	src <- 2
+	close(src)
+
+	out := BatchChannel(src, 100, time.Hour)
+	if batch := <-out; len(batch) != 2 {
+		t.Fatalf("expected the partial batch to be flushed on close, got %v", batch)
+	}
+	if _, ok := <-out; ok {
+		t.Fatal("expected the output to be closed")
+	}
+}
+
+func TestCollectAll(t *testing.T) {
+	empty := make(chan int)
+	close(empty)
+	if res := CollectAll(empty); res == nil || len(res) != 0 {
+		t.Fatalf("expected an empty slice, got %#v", res)
+	}
+
+	ch := make(chan int)
+	go func() {
+		for i := 0; i < 10000; i++ {
+			ch <- i
+		}
+		close(ch)
+	}()
+
+	res := CollectAll(ch)
+	if len(res) != 10000 || res[9999] != 9999 {
+		t.Fatalf("unexpected result of length %d", len(res))
+	}
+}
+
+func TestCollectN(t *testing.T) {
+	ch := make(chan int, 5)
+	for i := 0; i < 5; i++ {
+		ch <- i
+	}
+
+	if res := CollectN(ch, 3); len(res) != 3 || res[2] != 2 {
+		t.Fatalf("unexpected result %v", res)
+	}
+
+	close(ch)
+	if res := CollectN(ch, 10); len(res) != 2 || res[1] != 4 {
+		t.Fatalf("expected the remaining 2 items, got %v", res)
+	}
+	if res := CollectN(ch, -1); len(res) != 0 {
+		t.Fatalf("expected nothing for a negative n, got %v", res)
+	}
+}
+
+func TestMovingAggregate(t *testing.T) {
+	src := make(chan int)
+	go func() {
+		for _, v := range []int{4, 1, 7, 2, 9} {
+			src <- v
+		}
+		close(src)
+	}()
+
+	expected := []Aggregate[int]{
+		{Min: 1, Max: 7, Avg: 4, Count: 3},
+		{Min: 1, Max: 7, Avg: 10.0 / 3, Count: 3},
+		{Min: 2, Max: 9, Avg: 6, Count: 3},
+	}
+
+	if got := CollectAll(MovingAggregate(src, 3)); !SliceEqual(got, expected) {
+		t.Fatalf("expected %v, got %v", expected, got)
+	}
+
+	// The window never fills, so only the partial aggregate is emitted
+	short := make(chan float64, 2)
+	short <- 1.5
+	short <- 2.5
+	close(short)
+
+	got2 := CollectAll(MovingAggregate(short, 10))
+	if len(got2) != 1 || got2[0] != (Aggregate[float64]{Min: 1.5, Max: 2.5, Avg: 2, Count: 2}) {
+		t.Fatalf("expected a single partial aggregate, got %v", got2)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	chans := make([]<-chan int, 3)
+	for i := range chans {
+		ch := make(chan int)
+		chans[i] = ch
+		go func(n int) {
+			defer close(ch)
+			for j := 0; j < n; j++ {
+				ch <- j
+			}
+		}(i * 10)
+	}
+
+	got := CollectAll(Merge(context.Background(), chans...))
+	if len(got) != 30 {
+		t.Fatalf("expected 30 merged values, got %d", len(got))
+	}
+	if count := Count(got, 9); count != 2 {
+		t.Fatalf("expected 9 from both longer inputs, got it %d times", count)
+	}
+
+	if _, ok := <-Merge[int](context.Background()); ok {
+		t.Fatal("expected merging nothing to close straight away")
+	}
+}
+
+func TestMergeCancel(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// Never closed, only the cancellation can end the merge
+	a, b := make(chan int), make(chan int)
+	out := Merge(ctx, a, b)
+
+	go func() { a <- 1 }()
+	if v := <-out; v != 1 {
+		t.Fatalf("expected 1, got %d", v)
+	}
+
+	cancel()
+	for range out {
+	}
+
+	waitFor(t, func() bool { return runtime.NumGoroutine() <= before })
+}
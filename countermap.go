@@ -0,0 +1,53 @@
+package btils
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Goroutine-safe tally per key, e.g. per error type or tenant. Every key gets its own atomic counter held in a
+// sync.Map, so once a key exists, incrementing it never takes a lock and different keys never contend
+type CounterMap[K comparable] struct {
+	counters sync.Map // K -> *atomic.Int64
+}
+
+func NewCounterMap[K comparable]() *CounterMap[K] {
+	return &CounterMap[K]{}
+}
+
+func (m *CounterMap[K]) counter(key K) *atomic.Int64 {
+	if c, ok := m.counters.Load(key); ok {
+		return c.(*atomic.Int64)
+	}
+
+	c, _ := m.counters.LoadOrStore(key, new(atomic.Int64))
+	return c.(*atomic.Int64)
+}
+
+func (m *CounterMap[K]) Inc(key K) int64 {
+	return m.Add(key, 1)
+}
+
+// Returns the new count
+func (m *CounterMap[K]) Add(key K, n int64) int64 {
+	return m.counter(key).Add(n)
+}
+
+// Count for key, 0 if it was never incremented
+func (m *CounterMap[K]) Get(key K) int64 {
+	if c, ok := m.counters.Load(key); ok {
+		return c.(*atomic.Int64).Load()
+	}
+	return 0
+}
+
+// Plain copy of all counts. Counters are read one by one, so while other goroutines keep incrementing, the
+// snapshot isn't a consistent view across keys
+func (m *CounterMap[K]) Snapshot() map[K]int64 {
+	res := make(map[K]int64)
+	m.counters.Range(func(key, c any) bool {
+		res[key.(K)] = c.(*atomic.Int64).Load()
+		return true
+	})
+	return res
+}
@@ -0,0 +1,53 @@
+package btils
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestCounterMap(t *testing.T) {
+	m := NewCounterMap[string]()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				m.Inc("Foo")
+				m.Add("tenant-"+strconv.Itoa(j%4), 2)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if m.Get("Foo") != 16000 {
+		t.Fatalf("expected 16000, got %d", m.Get("Foo"))
+	}
+	if m.Get("Baar") != 0 {
+		t.Fatalf("expected 0 for a missing key, got %d", m.Get("Baar"))
+	}
+
+	snap := m.Snapshot()
+	if len(snap) != 5 || snap["tenant-3"] != 8000 {
+		t.Fatalf("unexpected snapshot %v", snap)
+	}
+
+	// The snapshot is a copy
+	snap["Foo"] = 0
+	if m.Get("Foo") != 16000 {
+		t.Fatal("modifying the snapshot changed the counter")
+	}
+}
+
+func BenchmarkCounterMap(b *testing.B) {
+	m := NewCounterMap[int]()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			m.Inc(i % 8)
+			i++
+		}
+	})
+}
@@ -0,0 +1,50 @@
+package btils
+
+import (
+	"bytes"
+	"io"
+	"sync"
+
+	"github.com/goccy/go-json"
+)
+
+// Pooled decoding for hot paths that decode the same message type over and over. Acquire a target, Decode into it,
+// use it and Release it again, so both the targets and the read buffers get reused
+type Decoder[T any] struct {
+	targets sync.Pool
+	buffers sync.Pool
+}
+
+func NewDecoder[T any]() *Decoder[T] {
+	return &Decoder[T]{
+		targets: sync.Pool{New: func() any { return new(T) }},
+		buffers: sync.Pool{New: func() any { return new(bytes.Buffer) }},
+	}
+}
+
+// Returns a zeroed *T from the pool
+func (d *Decoder[T]) Acquire() *T {
+	return d.targets.Get().(*T)
+}
+
+// Decodes rc into v, which should come from 'Acquire'
+func (d *Decoder[T]) Decode(rc io.Reader, v *T) error {
+	buf := d.buffers.Get().(*bytes.Buffer)
+	defer func() {
+		buf.Reset()
+		d.buffers.Put(buf)
+	}()
+
+	if _, err := buf.ReadFrom(rc); err != nil {
+		return err
+	}
+
+	return json.Unmarshal(buf.Bytes(), v)
+}
+
+// Zeroes v and returns it to the pool. Zeroing keeps data from one message leaking into the next, since decoding
+// only overwrites the fields present in the input. v must not be used afterwards
+func (d *Decoder[T]) Release(v *T) {
+	*v = None[T]()
+	d.targets.Put(v)
+}
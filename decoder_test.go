@@ -0,0 +1,60 @@
+package btils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDecoder(t *testing.T) {
+	d := NewDecoder[testPerson]()
+
+	p := d.Acquire()
+	if err := d.Decode(strings.NewReader(testPersonJSON), p); err != nil {
+		t.Fatal(err)
+	}
+	if p.Name != "Alice" || p.Age != 30 {
+		t.Fatalf("unexpected result %+v", p)
+	}
+
+	d.Release(p)
+	if p.Name != "" || p.Age != 0 {
+		t.Fatalf("expected Release to zero the target, got %+v", p)
+	}
+
+	// Whatever the pool hands out next must not carry data over
+	p = d.Acquire()
+	if err := d.Decode(strings.NewReader(`{"name": "Bob"}`), p); err != nil {
+		t.Fatal(err)
+	}
+	if p.Name != "Bob" || p.Age != 0 {
+		t.Fatalf("data leaked between decodes: %+v", p)
+	}
+	d.Release(p)
+}
+
+func BenchmarkDecoder(b *testing.B) {
+	d := NewDecoder[testPerson]()
+	r := strings.NewReader(testPersonJSON)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		r.Reset(testPersonJSON)
+		p := d.Acquire()
+		if err := d.Decode(r, p); err != nil {
+			b.Fatal(err)
+		}
+		d.Release(p)
+	}
+}
+
+func BenchmarkUnmarshal(b *testing.B) {
+	r := strings.NewReader(testPersonJSON)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		r.Reset(testPersonJSON)
+		if _, err := Unmarshal[testPerson](r); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package btils
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+var durationType = reflect.TypeFor[time.Duration]()
+
+// Like 'Unmarshal', but fields missing from the input are populated from their `default:"..."` struct tag, parsed
+// into the field's type. Supports strings, bools, all int, uint and float kinds and time.Duration (e.g. "5s"),
+// nested structs are handled recursively. Defaults are applied before decoding, so a field that is present in the
+// input always wins, even if it's explicitly set to its zero value. An unparsable tag is returned as an error
+func UnmarshalWithDefaults[T any](r io.Reader) (*T, error) {
+	var res T
+	if err := applyDefaults(reflect.ValueOf(&res).Elem()); err != nil {
+		return nil, err
+	}
+	return UnmarshalPointer(&res, r)
+}
+
+func applyDefaults(v reflect.Value) error {
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		fv := v.Field(i)
+		if fv.Kind() == reflect.Struct {
+			if err := applyDefaults(fv); err != nil {
+				return err
+			}
+			continue
+		}
+
+		def, ok := field.Tag.Lookup("default")
+		if !ok {
+			continue
+		}
+		if err := setDefault(fv, def); err != nil {
+			return fmt.Errorf("btils: default for field %s: %w", field.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func setDefault(v reflect.Value, def string) error {
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(def)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(def)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(def)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(def, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, err := strconv.ParseUint(def, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(def, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported kind %s", v.Kind())
+	}
+
+	return nil
+}
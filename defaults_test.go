@@ -0,0 +1,46 @@
+package btils
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUnmarshalWithDefaults(t *testing.T) {
+	type database struct {
+		Host string `json:"host" default:"localhost"`
+		Port uint16 `json:"port" default:"5432"`
+	}
+	type config struct {
+		Name    string        `json:"name" default:"Foo"`
+		Workers int           `json:"workers" default:"4"`
+		Debug   bool          `json:"debug" default:"true"`
+		Ratio   float64       `json:"ratio" default:"0.5"`
+		Timeout time.Duration `json:"timeout" default:"5s"`
+		Plain   string        `json:"plain"`
+		DB      database      `json:"db"`
+	}
+
+	cfg, err := UnmarshalWithDefaults[config](strings.NewReader(`{"name": "Baar", "debug": false, "db": {"port": 6543}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Present fields win, even when they are explicitly set to their zero value
+	if cfg.Name != "Baar" || cfg.Debug || cfg.DB.Port != 6543 {
+		t.Fatalf("expected present fields to override the defaults, got %+v", cfg)
+	}
+	if cfg.Workers != 4 || cfg.Ratio != 0.5 || cfg.Timeout != 5*time.Second || cfg.DB.Host != "localhost" {
+		t.Fatalf("expected absent fields to pick up their defaults, got %+v", cfg)
+	}
+	if cfg.Plain != "" {
+		t.Fatalf("expected fields without a default to stay zero, got %q", cfg.Plain)
+	}
+
+	type broken struct {
+		Workers int `default:"many"`
+	}
+	if _, err := UnmarshalWithDefaults[broken](strings.NewReader(`{}`)); err == nil || !strings.Contains(err.Error(), "Workers") {
+		t.Fatalf("expected an error naming the field with the bad default, got %v", err)
+	}
+}
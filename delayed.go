@@ -0,0 +1,141 @@
+package btils
+
+import (
+	"container/heap"
+	"context"
+	"time"
+)
+
+type delayedTask[T any] struct {
+	at time.Time
+	t  task[T]
+}
+
+// Min-heap of delayed tasks ordered by the time they're due, see container/heap
+type delayQueue[T any] []delayedTask[T]
+
+func (q delayQueue[T]) Len() int           { return len(q) }
+func (q delayQueue[T]) Less(i, j int) bool { return q[i].at.Before(q[j].at) }
+func (q delayQueue[T]) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+
+func (q *delayQueue[T]) Push(x any) {
+	*q = append(*q, x.(delayedTask[T]))
+}
+
+func (q *delayQueue[T]) Pop() any {
+	old := *q
+	res := old[len(old)-1]
+	old[len(old)-1] = delayedTask[T]{} // Don't keep a reference to the item around
+	*q = old[:len(old)-1]
+	return res
+}
+
+// Like 'FeedCtx', but in is only handed to the workers once delay has elapsed, turning the pool into a lightweight
+// scheduler for retries and timeouts. Returns right away, the item counts as pending in the meantime, so 'Wait'
+// waits for it too. Due items are queued by an internal goroutine, which blocks while the queue is full without
+// holding up the caller. With 'NewSyncThreadManager' the callback also runs on that goroutine instead of the one
+// calling FeedAfter. Items still waiting, or still on their way into a full queue, when the pool is stopped are
+// never processed and are reported by 'Unprocessed' instead. Returns ErrPoolStopped if the pool has been stopped
+// already
+func (tm *ThreaderManager[T]) FeedAfter(in T, delay time.Duration) error {
+	if delay <= 0 {
+		return tm.FeedCtx(context.Background(), in)
+	}
+
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	// Checked under tm.mu so 'flushDelayed' can't miss an item that's added concurrently
+	if tm.queue.isClosed() {
+		return ErrPoolStopped
+	}
+
+	tm.add()
+	heap.Push(&tm.delayed, delayedTask[T]{at: time.Now().Add(delay), t: task[T]{in: in}})
+
+	if tm.delayedWake == nil {
+		var ctx context.Context
+		ctx, tm.delayedCancel = context.WithCancel(context.Background())
+		tm.delayedWake = make(chan struct{}, 1)
+		go tm.scheduleDelayed(ctx, tm.delayedWake)
+	}
+
+	select {
+	case tm.delayedWake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Hands items to the workers as they become due, until ctx is done
+func (tm *ThreaderManager[T]) scheduleDelayed(ctx context.Context, wake <-chan struct{}) {
+	for {
+		var due []task[T]
+		var timer <-chan time.Time
+
+		tm.mu.Lock()
+		now := time.Now()
+		for len(tm.delayed) > 0 && !tm.delayed[0].at.After(now) {
+			due = append(due, heap.Pop(&tm.delayed).(delayedTask[T]).t)
+		}
+		if len(tm.delayed) > 0 {
+			timer = time.After(tm.delayed[0].at.Sub(now))
+		}
+		tm.mu.Unlock()
+
+		for _, t := range due {
+			tm.dispatch(ctx, t)
+		}
+
+		select {
+		case <-timer:
+		case <-wake:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Queues a due item that has already been counted by 'FeedAfter'. Blocks while the queue is full until ctx is done
+func (tm *ThreaderManager[T]) dispatch(ctx context.Context, t task[T]) {
+	if tm.inFlight != nil {
+		if tm.inFlight.Acquire(ctx) != nil {
+			tm.abandon(t)
+			return
+		}
+	}
+
+	if tm.sync {
+		if !tm.queue.isClosed() {
+			tm.finish(t, tm.process(t.in), time.Now())
+			return
+		}
+	} else if tm.queue.push(t, ctx.Done()) == nil {
+		tm.wake()
+		return
+	}
+
+	// Stopped while the item was on its way
+	if tm.inFlight != nil {
+		tm.inFlight.Release()
+	}
+	tm.abandon(t)
+}
+
+// Gives up on every item that's still waiting for its delay and stops 'scheduleDelayed', see 'Unprocessed'
+func (tm *ThreaderManager[T]) flushDelayed() {
+	var pending []task[T]
+
+	tm.mu.Lock()
+	for len(tm.delayed) > 0 {
+		pending = append(pending, heap.Pop(&tm.delayed).(delayedTask[T]).t)
+	}
+	if tm.delayedCancel != nil {
+		tm.delayedCancel()
+	}
+	tm.mu.Unlock()
+
+	for _, t := range pending {
+		tm.abandon(t)
+	}
+}
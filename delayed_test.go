@@ -0,0 +1,121 @@
+package btils
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFeedAfter(t *testing.T) {
+	var mu sync.Mutex
+	handled := map[string]time.Time{}
+	var order []string
+	tm := NewThreadManager(1, func(in string) {
+		mu.Lock()
+		handled[in] = time.Now()
+		order = append(order, in)
+		mu.Unlock()
+	})
+
+	tm.Start()
+	defer tm.Stop()
+
+	start := time.Now()
+	if err := tm.FeedAfter("Baar", 150*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if err := tm.FeedAfter("Foo", 75*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if err := tm.FeedAfter("Baloo", 0); err != nil {
+		t.Fatal(err)
+	}
+
+	waitFor(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(order) == 1
+	})
+	if pending := tm.Stats().Pending; pending != 2 {
+		t.Fatalf("expected the delayed items to be pending, got %d", pending)
+	}
+
+	tm.Wait()
+
+	if !SliceEqual(order, []string{"Baloo", "Foo", "Baar"}) {
+		t.Fatalf("expected items in the order they became due, got %v", order)
+	}
+	if d := handled["Foo"].Sub(start); d < 75*time.Millisecond {
+		t.Fatalf("expected Foo to wait for its delay, was processed after %v", d)
+	}
+	if d := handled["Baar"].Sub(start); d < 150*time.Millisecond {
+		t.Fatalf("expected Baar to wait for its delay, was processed after %v", d)
+	}
+}
+
+func TestFeedAfterStopped(t *testing.T) {
+	called := false
+	tm := NewThreadManager(1, func(in string) { called = true })
+
+	tm.Start()
+	if err := tm.FeedAfter("Foo", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	tm.CloseAndDrain()
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("expected stopping to not wait for the delay, took %v", elapsed)
+	}
+
+	if called {
+		t.Fatal("expected the delayed item to not be processed")
+	}
+	if remaining := tm.Unprocessed(); !SliceEqual(remaining, []string{"Foo"}) {
+		t.Fatalf("expected the delayed item to be reported, got %v", remaining)
+	}
+	if err := tm.FeedAfter("Baar", time.Millisecond); !errors.Is(err, ErrPoolStopped) {
+		t.Fatalf("expected ErrPoolStopped, got %v", err)
+	}
+}
+
+func TestFeedAfterBlockedDispatch(t *testing.T) {
+	tm := NewThreadManager(1, func(in int) {}, WithMaxInFlight(1))
+
+	// Never started, so the due item can't get an in-flight slot until the pool is stopped
+	tm.Feed(0)
+	if err := tm.FeedAfter(1, time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	tm.Stop()
+	waitFor(t, func() bool { return SliceEqual(tm.Unprocessed(), []int{1}) })
+	if pending := tm.Stats().Pending; pending != 1 {
+		t.Fatalf("expected only the queued item to be pending, got %d", pending)
+	}
+}
+
+func TestFeedAfterSync(t *testing.T) {
+	var mu sync.Mutex
+	var handled []string
+	tm := NewSyncThreadManager(func(in string) {
+		mu.Lock()
+		handled = append(handled, in)
+		mu.Unlock()
+	})
+
+	start := time.Now()
+	if err := tm.FeedAfter("Foo", 20*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if time.Since(start) >= 20*time.Millisecond {
+		t.Fatal("expected FeedAfter to return before the delay elapsed")
+	}
+
+	tm.Wait()
+	if !SliceEqual(handled, []string{"Foo"}) || time.Since(start) < 20*time.Millisecond {
+		t.Fatalf("expected Foo to be processed after its delay, got %v", handled)
+	}
+}
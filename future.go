@@ -0,0 +1,38 @@
+package btils
+
+// Completion signal for a single item fed through 'FeedFuture'
+type Future struct {
+	done chan struct{}
+	err  error
+}
+
+func newFuture() *Future {
+	return &Future{done: make(chan struct{})}
+}
+
+// Must only be called once
+func (f *Future) resolve(err error) {
+	f.err = err
+	close(f.done)
+}
+
+// Closed once the item has been processed
+func (f *Future) Done() <-chan struct{} {
+	return f.done
+}
+
+// Blocks until the item has been processed and returns its error, see 'Err'
+func (f *Future) Wait() error {
+	<-f.done
+	return f.err
+}
+
+// Error the pool produced for the item, e.g. an *ItemError for a timeout. Returns nil while still pending
+func (f *Future) Err() error {
+	select {
+	case <-f.done:
+		return f.err
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,127 @@
+package btils
+
+import (
+	"hash/fnv"
+	"slices"
+	"strconv"
+	"sync"
+)
+
+// Default number of virtual points every node gets on a 'HashRing'
+const defaultRingReplicas = 128
+
+// Goroutine-safe consistent hash ring mapping UIDs to nodes. Adding or removing a node only moves the keys that
+// land on its share of the ring, roughly 1/n of them, instead of reshuffling everything
+type HashRing struct {
+	mu       sync.RWMutex
+	replicas int
+	points   []ringPoint // Sorted by hash
+	nodes    map[string]bool
+}
+
+type ringPoint struct {
+	hash uint64
+	node string
+}
+
+// replicas is the number of virtual points per node, more of them spread keys more evenly.
+// Values below 1 use a default of 128
+func NewHashRing(replicas int, nodes ...string) *HashRing {
+	r := &HashRing{
+		replicas: If(replicas > 0, replicas, defaultRingReplicas),
+		nodes:    make(map[string]bool),
+	}
+
+	for _, node := range nodes {
+		r.Add(node)
+	}
+
+	return r
+}
+
+// splitmix64 finalizer
+func mix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+func ringHash(node string, replica int) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(node))
+	h.Write([]byte{'#'})
+	h.Write([]byte(strconv.Itoa(replica)))
+	return mix64(h.Sum64())
+}
+
+func (r *HashRing) Add(node string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.nodes[node] {
+		return
+	}
+	r.nodes[node] = true
+
+	for i := 0; i < r.replicas; i++ {
+		r.points = append(r.points, ringPoint{hash: ringHash(node, i), node: node})
+	}
+	slices.SortFunc(r.points, func(a, b ringPoint) int {
+		if a.hash != b.hash {
+			return If(a.hash < b.hash, -1, 1)
+		}
+		// Tie-break on the name so the ring doesn't depend on insertion order
+		return If(a.node < b.node, -1, If(a.node > b.node, 1, 0))
+	})
+}
+
+func (r *HashRing) Remove(node string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.nodes[node] {
+		return
+	}
+	delete(r.nodes, node)
+
+	r.points = slices.DeleteFunc(r.points, func(p ringPoint) bool {
+		return p.node == node
+	})
+}
+
+// Node responsible for uid, or "" if the ring is empty
+func (r *HashRing) Get(uid UID) string {
+	hi, lo := uid.Uint128()
+	hash := mix64(hi ^ mix64(lo))
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if len(r.points) == 0 {
+		return ""
+	}
+
+	i, _ := slices.BinarySearchFunc(r.points, hash, func(p ringPoint, hash uint64) int {
+		return If(p.hash < hash, -1, If(p.hash > hash, 1, 0))
+	})
+	if i == len(r.points) {
+		i = 0 // Wrap around
+	}
+
+	return r.points[i].node
+}
+
+// Nodes currently on the ring, in no particular order
+func (r *HashRing) Nodes() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	res := make([]string, 0, len(r.nodes))
+	for node := range r.nodes {
+		res = append(res, node)
+	}
+	return res
+}
@@ -0,0 +1,52 @@
+package btils
+
+import "testing"
+
+func TestHashRing(t *testing.T) {
+	r := NewHashRing(0, "a", "b", "c", "d")
+
+	uids := make([]UID, 10000)
+	before := make([]string, len(uids))
+	counts := map[string]int{}
+	for i := range uids {
+		NewUID(&uids[i])
+		before[i] = r.Get(uids[i])
+		counts[before[i]]++
+	}
+
+	for _, node := range []string{"a", "b", "c", "d"} {
+		if counts[node] < 1500 {
+			t.Fatalf("node %s only got %d of 10000 keys: %v", node, counts[node], counts)
+		}
+	}
+
+	r.Add("e")
+
+	moved := 0
+	for i, uid := range uids {
+		after := r.Get(uid)
+		if after == before[i] {
+			continue
+		}
+		if after != "e" {
+			t.Fatalf("key moved from %s to %s instead of the new node", before[i], after)
+		}
+		moved++
+	}
+
+	// Ideally 1/5 of the keys move to the new node
+	if moved == 0 || moved > 3500 {
+		t.Fatalf("expected a bounded fraction of keys to move, moved %d of 10000", moved)
+	}
+
+	r.Remove("e")
+	for i, uid := range uids {
+		if r.Get(uid) != before[i] {
+			t.Fatal("removing the node again should restore the original mapping")
+		}
+	}
+
+	if NewHashRing(0).Get(uids[0]) != "" {
+		t.Fatal("expected an empty ring to return no node")
+	}
+}
@@ -0,0 +1,79 @@
+package btils
+
+import (
+	"sync"
+	"time"
+)
+
+// How often 'IdempotencyStore' scans all entries to evict expired ones
+const idempotencySweepInterval = time.Minute
+
+// Goroutine-safe record of recently seen UIDs, e.g. idempotency keys of incoming requests. Expired entries are
+// evicted lazily: a UID is checked when it's seen again, and at most once per minute a call to Seen sweeps all
+// expired entries. There is no background goroutine to stop
+type IdempotencyStore struct {
+	mu        sync.Mutex
+	entries   map[UID]time.Time
+	lastSweep time.Time
+
+	now func() time.Time
+}
+
+type IdempotencyStoreOption func(*IdempotencyStore)
+
+// Replaces the clock ttls are measured with, e.g. with a fake one in tests. Defaults to time.Now
+func WithIdempotencyClock(now func() time.Time) IdempotencyStoreOption {
+	return func(s *IdempotencyStore) {
+		s.now = now
+	}
+}
+
+func NewIdempotencyStore(opts ...IdempotencyStoreOption) *IdempotencyStore {
+	s := &IdempotencyStore{
+		entries: make(map[UID]time.Time),
+		now:     time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Reports whether uid was already recorded within its ttl. If it wasn't, it's recorded for ttl from now, so of
+// several concurrent calls for the same uid exactly one returns false
+func (s *IdempotencyStore) Seen(uid UID, ttl time.Duration) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if now.Sub(s.lastSweep) >= idempotencySweepInterval {
+		s.sweep(now)
+	}
+
+	if expires, ok := s.entries[uid]; ok && now.Before(expires) {
+		return true
+	}
+
+	s.entries[uid] = now.Add(ttl)
+	return false
+}
+
+// Has to be called with s.mu held
+func (s *IdempotencyStore) sweep(now time.Time) {
+	for uid, expires := range s.entries {
+		if !now.Before(expires) {
+			delete(s.entries, uid)
+		}
+	}
+	s.lastSweep = now
+}
+
+// Number of recorded UIDs, including expired ones that haven't been evicted yet
+func (s *IdempotencyStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.entries)
+}
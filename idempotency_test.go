@@ -0,0 +1,63 @@
+package btils
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestIdempotencyStore(t *testing.T) {
+	now := time.Unix(1000, 0)
+	s := NewIdempotencyStore(WithIdempotencyClock(func() time.Time { return now }))
+
+	a := *UIDFromString("AbCdEfGh_-012345")
+	b := *UIDFromString("AbCdEfGh_-012346")
+
+	if s.Seen(a, 10*time.Second) {
+		t.Fatal("expected a new UID to not be seen")
+	}
+	if !s.Seen(a, 10*time.Second) {
+		t.Fatal("expected the UID to be seen within its ttl")
+	}
+	if s.Seen(b, time.Hour) {
+		t.Fatal("expected a different UID to not be seen")
+	}
+
+	now = now.Add(10 * time.Second)
+	if s.Seen(a, 10*time.Second) {
+		t.Fatal("expected the UID to not be seen once expired")
+	}
+
+	// The sweep evicts a, which expired again, but keeps b
+	now = now.Add(idempotencySweepInterval)
+	s.Seen(*UIDFromString("AbCdEfGh_-012347"), time.Second)
+	if s.Len() != 2 {
+		t.Fatalf("expected the expired entry to be swept, got %d entries", s.Len())
+	}
+	if !s.Seen(b, time.Hour) {
+		t.Fatal("expected the sweep to keep entries that haven't expired")
+	}
+}
+
+func TestIdempotencyStoreConcurrent(t *testing.T) {
+	s := NewIdempotencyStore()
+	uid := Generate()
+
+	var firsts atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < 32; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if !s.Seen(uid, time.Hour) {
+				firsts.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if firsts.Load() != 1 {
+		t.Fatalf("expected exactly one caller to see the UID first, got %d", firsts.Load())
+	}
+}
@@ -1,11 +1,22 @@
 package btils
 
 import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
 	"io"
+	"os"
+	"runtime"
+	"sync/atomic"
 
 	"github.com/goccy/go-json"
 )
 
+// Returned by the streaming decoders when the reader holds more than the one expected JSON value
+var ErrTrailingData = errors.New("btils: unexpected data after JSON value")
+
 // Unmarshal a reader into T and return *T
 func Unmarshal[T any](rc io.Reader) (*T, error) {
 	b, err := io.ReadAll(rc)
@@ -22,6 +33,23 @@ func Unmarshal[T any](rc io.Reader) (*T, error) {
 	return &res, nil
 }
 
+// Like 'Unmarshal', but also returns the raw bytes that were read, so non-seekable readers don't have to be read
+// twice when the original document needs to be kept around. The bytes are returned even if decoding fails
+func UnmarshalKeepRaw[T any](r io.Reader) (*T, []byte, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, b, err
+	}
+
+	var res T
+	err = json.Unmarshal(b, &res)
+	if err != nil {
+		return nil, b, err
+	}
+
+	return &res, b, nil
+}
+
 // Can be slightly faster than 'Unmarshal' since the pointer is passed down
 func UnmarshalPointer[T any](in *T, rc io.Reader) (*T, error) {
 	b, err := io.ReadAll(rc)
@@ -36,3 +64,243 @@ func UnmarshalPointer[T any](in *T, rc io.Reader) (*T, error) {
 
 	return in, nil
 }
+
+// Like 'Unmarshal', but numbers decoded into interface{} values (e.g. map[string]any) become json.Number instead of
+// float64, so integers above 2^53 and exact decimals keep their precision
+func UnmarshalNumberSafe[T any](rc io.Reader) (*T, error) {
+	dec := json.NewDecoder(rc)
+	dec.UseNumber()
+
+	var res T
+	err := dec.Decode(&res)
+	if err != nil {
+		return nil, err
+	}
+	if err := expectEOF(dec); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// Decoding a single value stops right after it, so anything but whitespace left in the reader is an error
+func expectEOF(dec *json.Decoder) error {
+	if dec.More() {
+		return ErrTrailingData
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return ErrTrailingData
+	}
+	return nil
+}
+
+// Like 'Unmarshal', but transparently decompresses the reader first if it starts with the gzip magic bytes.
+// Sniffing happens on a buffered peek, so no bytes are lost for uncompressed input
+func UnmarshalMaybeGzip[T any](rc io.Reader) (*T, error) {
+	br := bufio.NewReader(rc)
+
+	magic, err := br.Peek(2)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	if len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+
+		return Unmarshal[T](gz)
+	}
+
+	return Unmarshal[T](br)
+}
+
+// How many bytes of input 'DecodeError' includes on either side of the offset
+const decodeErrorContext = 20
+
+// Describes where in the document decoding failed
+type DecodeError struct {
+	// Byte offset the decoder had reached when it failed, -1 if unknown
+	Offset int64
+	// Go field path of the value that failed to decode, e.g. "Address.Zip". Empty if unknown
+	Path string
+	// Input surrounding Offset
+	Snippet string
+
+	Err error
+}
+
+func (e *DecodeError) Error() string {
+	if e.Path != "" {
+		return fmt.Sprintf("%s (offset %d, field %s, near %q)", e.Err, e.Offset, e.Path, e.Snippet)
+	}
+	return fmt.Sprintf("%s (offset %d, near %q)", e.Err, e.Offset, e.Snippet)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+func newDecodeError(b []byte, err error) *DecodeError {
+	de := &DecodeError{Offset: -1, Err: err}
+
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		de.Offset = syntaxErr.Offset
+	case errors.As(err, &typeErr):
+		de.Offset = typeErr.Offset
+		de.Path = typeErr.Field
+	}
+
+	if de.Offset >= 0 {
+		offset := min(int(de.Offset), len(b))
+		de.Snippet = string(b[max(offset-decodeErrorContext, 0):min(offset+decodeErrorContext, len(b))])
+	}
+
+	return de
+}
+
+// Like 'Unmarshal', but decode failures additionally return a *DecodeError carrying the offset, field path and a
+// snippet of the surrounding input. The returned error is the same *DecodeError in that case, so checking err alone
+// is enough. Errors while reading rc are returned as-is with a nil *DecodeError
+func UnmarshalReport[T any](rc io.Reader) (*T, *DecodeError, error) {
+	b, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var res T
+	err = json.Unmarshal(b, &res)
+	if err != nil {
+		de := newDecodeError(b, err)
+		return nil, de, de
+	}
+
+	return &res, nil, nil
+}
+
+// Decodes every file in paths concurrently on a pool of workers. Results are in input order, files that failed
+// to decode leave a nil entry. All errors are joined, each one prefixed with its path. With stopOnError set, files
+// that haven't been started yet are skipped (and stay nil) once a file failed. workers below 1 uses GOMAXPROCS
+func UnmarshalFiles[T any](paths []string, workers int, stopOnError bool) ([]*T, error) {
+	if workers < 1 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	res := make([]*T, len(paths))
+	errs := make([]error, len(paths))
+
+	var failed atomic.Bool
+	tm := NewThreadManager[int](workers, func(i int) {
+		if stopOnError && failed.Load() {
+			return
+		}
+
+		f, err := os.Open(paths[i])
+		if err != nil {
+			errs[i] = err
+			failed.Store(true)
+			return
+		}
+		defer f.Close()
+
+		res[i], err = Unmarshal[T](f)
+		if err != nil {
+			errs[i] = fmt.Errorf("%s: %w", paths[i], err)
+			failed.Store(true)
+		}
+	})
+
+	tm.Start()
+	for i := range paths {
+		tm.Feed(i)
+	}
+	tm.CloseAndDrain()
+
+	return res, errors.Join(errs...)
+}
+
+// Decodes a stream of JSON values, e.g. JSON Lines, and feeds each one into tm as it's decoded, so the stream
+// never has to be held in memory. Feeding blocks while the pool's queue is full, which pushes back on reading r.
+// Returns nil on EOF, the decode error for a malformed value or ErrPoolStopped if tm is stopped mid-stream.
+// Doesn't wait for the fed values to be processed, call tm.Wait for that
+func StreamDecodeToPool[T any](r io.Reader, tm *ThreaderManager[*T]) error {
+	dec := json.NewDecoder(r)
+
+	for i := 0; ; i++ {
+		v := new(T)
+		if err := dec.Decode(v); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return fmt.Errorf("btils: decoding value %d: %w", i, err)
+		}
+
+		if err := tm.FeedCtx(context.Background(), v); err != nil {
+			return err
+		}
+	}
+}
+
+// Streams a JSON array from r to w, decoding one element at a time, passing it through fn and encoding the result
+// straight away, so arbitrarily large arrays never have to be held in memory.
+// Stops at the first error from fn, in which case w contains an incomplete array
+func TransformJSONArray[In, Out any](r io.Reader, w io.Writer, fn func(In) (Out, error)) error {
+	dec := json.NewDecoder(r)
+
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return fmt.Errorf("btils: expected a JSON array, got %v", tok)
+	}
+
+	if _, err := io.WriteString(w, "["); err != nil {
+		return err
+	}
+
+	for i := 0; dec.More(); i++ {
+		var in In
+		if err := dec.Decode(&in); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return err
+		}
+
+		out, err := fn(in)
+		if err != nil {
+			return fmt.Errorf("btils: transforming element %d: %w", i, err)
+		}
+
+		b, err := json.Marshal(out)
+		if err != nil {
+			return err
+		}
+
+		if i > 0 {
+			if _, err := io.WriteString(w, ","); err != nil {
+				return err
+			}
+		}
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+	}
+
+	// Consume the closing bracket so truncated input is reported instead of silently accepted
+	if _, err := dec.Token(); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return err
+	}
+
+	_, err = io.WriteString(w, "]")
+	return err
+}
@@ -0,0 +1,287 @@
+package btils
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/goccy/go-json"
+)
+
+type testPerson struct {
+	Name string `json:"name"`
+	Age  int    `json:"age"`
+}
+
+const testPersonJSON = `{"name": "Alice", "age": 30}`
+
+func TestUnmarshalMaybeGzip(t *testing.T) {
+	person, err := UnmarshalMaybeGzip[testPerson](strings.NewReader(testPersonJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if person.Name != "Alice" || person.Age != 30 {
+		t.Fatalf("unexpected uncompressed result %+v", person)
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	gz.Write([]byte(testPersonJSON))
+	gz.Close()
+
+	person, err = UnmarshalMaybeGzip[testPerson](&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if person.Name != "Alice" || person.Age != 30 {
+		t.Fatalf("unexpected compressed result %+v", person)
+	}
+
+	if _, err := UnmarshalMaybeGzip[testPerson](strings.NewReader("")); err == nil {
+		t.Fatal("expected an error for empty input")
+	}
+}
+
+func TestUnmarshalReport(t *testing.T) {
+	person, de, err := UnmarshalReport[testPerson](strings.NewReader(testPersonJSON))
+	if err != nil || de != nil || person.Name != "Alice" {
+		t.Fatalf("unexpected happy path result %+v %v %v", person, de, err)
+	}
+
+	cases := []struct {
+		input string
+		path  string
+	}{
+		{`{"name": "Alice", "age": "thirty"}`, "Age"},
+		{`{"name": "Alice", "age": 30,}`, ""},
+		{`{"name": "Alice" "age": 30}`, ""},
+	}
+
+	for _, c := range cases {
+		_, de, err := UnmarshalReport[testPerson](strings.NewReader(c.input))
+		if err == nil || de == nil {
+			t.Fatalf("expected a DecodeError for %s", c.input)
+		}
+		if err != error(de) {
+			t.Fatalf("expected err to be the DecodeError for %s", c.input)
+		}
+		if de.Offset <= 0 || de.Offset > int64(len(c.input)) {
+			t.Fatalf("unexpected offset %d for %s", de.Offset, c.input)
+		}
+		if de.Path != c.path {
+			t.Fatalf("expected path %q, got %q for %s", c.path, de.Path, c.input)
+		}
+		if de.Snippet == "" || !strings.Contains(c.input, de.Snippet) {
+			t.Fatalf("unexpected snippet %q for %s", de.Snippet, c.input)
+		}
+	}
+}
+
+func TestTransformJSONArray(t *testing.T) {
+	var in strings.Builder
+	in.WriteString("[")
+	for i := 0; i < 10000; i++ {
+		if i > 0 {
+			in.WriteString(",")
+		}
+		in.WriteString(`{"name": "Alice", "age": ` + strconv.Itoa(i) + `}`)
+	}
+	in.WriteString("]")
+
+	var out bytes.Buffer
+	err := TransformJSONArray(strings.NewReader(in.String()), &out, func(p testPerson) (int, error) {
+		return p.Age * 2, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := Unmarshal[[]int](&out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*res) != 10000 || (*res)[9999] != 19998 {
+		t.Fatalf("unexpected transform result of length %d", len(*res))
+	}
+
+	errBoom := errors.New("boom")
+	calls := 0
+	err = TransformJSONArray(strings.NewReader(in.String()), io.Discard, func(p testPerson) (int, error) {
+		calls++
+		if p.Age == 50 {
+			return 0, errBoom
+		}
+		return p.Age, nil
+	})
+	if !errors.Is(err, errBoom) {
+		t.Fatalf("expected the transform error, got %v", err)
+	}
+	if calls != 51 {
+		t.Fatalf("expected the transform to stop after 51 calls, got %d", calls)
+	}
+
+	if err := TransformJSONArray(strings.NewReader(`{"name": "Alice"}`), io.Discard, func(p testPerson) (int, error) {
+		return 0, nil
+	}); err == nil {
+		t.Fatal("expected an error for a non-array document")
+	}
+
+	for _, truncated := range []string{`[1,2`, `[1`, `[`} {
+		err := TransformJSONArray(strings.NewReader(truncated), io.Discard, func(n int) (int, error) {
+			return n, nil
+		})
+		if !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Fatalf("expected io.ErrUnexpectedEOF for %q, got %v", truncated, err)
+		}
+	}
+	if err := TransformJSONArray(strings.NewReader(`[1,`), io.Discard, func(n int) (int, error) {
+		return n, nil
+	}); err == nil {
+		t.Fatal("expected an error for input truncated after a comma")
+	}
+}
+
+func TestUnmarshalNumberSafe(t *testing.T) {
+	const doc = `{"id": 9007199254740993, "price": 0.1}`
+
+	res, err := UnmarshalNumberSafe[map[string]any](strings.NewReader(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	id, ok := (*res)["id"].(json.Number)
+	if !ok || id.String() != "9007199254740993" {
+		t.Fatalf("expected the id to keep its precision, got %#v", (*res)["id"])
+	}
+	if price := (*res)["price"].(json.Number); price.String() != "0.1" {
+		t.Fatalf("unexpected price %v", price)
+	}
+
+	b, err := json.Marshal(res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), "9007199254740993") {
+		t.Fatalf("round trip lost precision: %s", b)
+	}
+
+	if _, err := UnmarshalNumberSafe[map[string]any](strings.NewReader(doc + " \n")); err != nil {
+		t.Fatalf("trailing whitespace should be fine, got %v", err)
+	}
+	for _, bad := range []string{doc + " trailing", doc + ` {"id": 1}`} {
+		if _, err := UnmarshalNumberSafe[map[string]any](strings.NewReader(bad)); !errors.Is(err, ErrTrailingData) {
+			t.Fatalf("expected ErrTrailingData for %q, got %v", bad, err)
+		}
+	}
+
+	// The default Unmarshal stays lossy
+	lossy, _ := Unmarshal[map[string]any](strings.NewReader(doc))
+	if _, ok := (*lossy)["id"].(float64); !ok {
+		t.Fatalf("expected Unmarshal to still decode into float64, got %T", (*lossy)["id"])
+	}
+}
+
+func TestUnmarshalFiles(t *testing.T) {
+	dir := t.TempDir()
+
+	files := []string{testPersonJSON, `{"name": "Bob", "age": "old"}`, `{"name": "Carol", "age": 40}`}
+	paths := make([]string, len(files))
+	for i, content := range files {
+		paths[i] = filepath.Join(dir, strconv.Itoa(i)+".json")
+		if err := os.WriteFile(paths[i], []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	paths = append(paths, filepath.Join(dir, "missing.json"))
+
+	people, err := UnmarshalFiles[testPerson](paths, 2, false)
+	if err == nil {
+		t.Fatal("expected an error for the invalid and the missing file")
+	}
+	if !strings.Contains(err.Error(), paths[1]) || !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected the errors to name the failing files, got %v", err)
+	}
+
+	if len(people) != 4 || people[1] != nil || people[3] != nil {
+		t.Fatalf("expected nil entries for the failed files, got %v", people)
+	}
+	if people[0].Name != "Alice" || people[2].Name != "Carol" {
+		t.Fatalf("expected results in input order, got %+v, %+v", people[0], people[2])
+	}
+
+	// With a single worker, everything after the first failure is skipped
+	people, err = UnmarshalFiles[testPerson](paths, 1, true)
+	if err == nil || strings.Contains(err.Error(), "missing.json") {
+		t.Fatalf("expected only the first failure to be reported, got %v", err)
+	}
+	if people[0] == nil || people[2] != nil {
+		t.Fatalf("expected files after the failure to be skipped, got %v", people)
+	}
+
+	// Without a worker count it falls back to GOMAXPROCS instead of blocking forever
+	people, err = UnmarshalFiles[testPerson](append(paths[:1:1], paths[2]), 0, false)
+	if err != nil || len(people) != 2 || people[0].Name != "Alice" || people[1].Name != "Carol" {
+		t.Fatalf("unexpected result %v %v", people, err)
+	}
+}
+
+func TestUnmarshalKeepRaw(t *testing.T) {
+	person, raw, err := UnmarshalKeepRaw[testPerson](strings.NewReader(testPersonJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if person.Name != "Alice" || person.Age != 30 {
+		t.Fatalf("unexpected result %+v", person)
+	}
+	if string(raw) != testPersonJSON {
+		t.Fatalf("expected the raw bytes to match the input, got %q", raw)
+	}
+
+	_, raw, err = UnmarshalKeepRaw[testPerson](strings.NewReader(`{"name": 1}`))
+	if err == nil || string(raw) != `{"name": 1}` {
+		t.Fatalf("expected an error along with the raw bytes, got %v and %q", err, raw)
+	}
+}
+
+func TestStreamDecodeToPool(t *testing.T) {
+	var mu sync.Mutex
+	ages := 0
+	tm := NewThreadManager[*testPerson](2, func(in *testPerson) {
+		mu.Lock()
+		ages += in.Age
+		mu.Unlock()
+	})
+
+	tm.Start()
+	defer tm.Stop()
+
+	stream := testPersonJSON + "\n" + `{"name": "Bob", "age": 12}` + "\n" + `{"name": "Carol", "age": 1}` + "\n"
+	if err := StreamDecodeToPool(strings.NewReader(stream), tm); err != nil {
+		t.Fatal(err)
+	}
+	tm.Wait()
+
+	if ages != 43 {
+		t.Fatalf("expected all 3 values to be processed, got an age sum of %d", ages)
+	}
+	if stats := tm.Stats(); stats.Processed != 3 {
+		t.Fatalf("expected 3 processed values, got %+v", stats)
+	}
+
+	err := StreamDecodeToPool(strings.NewReader(testPersonJSON+`{"name": `), tm)
+	if err == nil || !strings.Contains(err.Error(), "value 1") {
+		t.Fatalf("expected a decode error for the second value, got %v", err)
+	}
+
+	tm.CloseAndDrain()
+	if err := StreamDecodeToPool(strings.NewReader(testPersonJSON), tm); err != ErrPoolStopped {
+		t.Fatalf("expected ErrPoolStopped, got %v", err)
+	}
+}
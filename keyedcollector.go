@@ -0,0 +1,76 @@
+package btils
+
+import "sync"
+
+// Matches results back to whoever submitted the work, e.g. to hand responses processed by a pool back to their
+// callers. The worker calls Resolve with the submission's key once it's done and the caller blocks in Await for
+// that same key. Either side may come first. Every key is meant to be resolved and awaited exactly once, entries
+// are removed once a result has been handed to Await
+type KeyedCollector[K comparable, V any] struct {
+	mu      sync.Mutex
+	entries map[K]*keyedResult[V]
+}
+
+type keyedResult[V any] struct {
+	done     chan struct{}
+	resolved bool
+	value    V
+	err      error
+}
+
+func NewKeyedCollector[K comparable, V any]() *KeyedCollector[K, V] {
+	return &KeyedCollector[K, V]{
+		entries: make(map[K]*keyedResult[V]),
+	}
+}
+
+// Has to be called with c.mu held
+func (c *KeyedCollector[K, V]) entry(key K) *keyedResult[V] {
+	e, ok := c.entries[key]
+	if !ok {
+		e = &keyedResult[V]{done: make(chan struct{})}
+		c.entries[key] = e
+	}
+	return e
+}
+
+// Stores the result for key, waking up its Await. Resolving a key again before it has been awaited has no effect
+func (c *KeyedCollector[K, V]) Resolve(key K, value V, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e := c.entry(key)
+	if e.resolved {
+		return
+	}
+
+	e.resolved = true
+	e.value = value
+	e.err = err
+	close(e.done)
+}
+
+// Blocks until key is resolved and returns its result
+func (c *KeyedCollector[K, V]) Await(key K) (V, error) {
+	c.mu.Lock()
+	e := c.entry(key)
+	c.mu.Unlock()
+
+	<-e.done
+
+	c.mu.Lock()
+	if c.entries[key] == e {
+		delete(c.entries, key)
+	}
+	c.mu.Unlock()
+
+	return e.value, e.err
+}
+
+// Number of keys that have been resolved but not awaited yet, or awaited but not resolved yet
+func (c *KeyedCollector[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.entries)
+}
@@ -0,0 +1,46 @@
+package btils
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestKeyedCollector(t *testing.T) {
+	c := NewKeyedCollector[int, string]()
+
+	// Workers resolve keys in whatever order the pool finishes them
+	tm := NewThreadManager[int](4, func(in int) {
+		c.Resolve(in, strconv.Itoa(in*2), nil)
+	})
+	tm.Start()
+	defer tm.Stop()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			tm.Feed(i)
+			v, err := c.Await(i)
+			if err != nil || v != strconv.Itoa(i*2) {
+				t.Errorf("expected %d for key %d, got %q, %v", i*2, i, v, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if c.Len() != 0 {
+		t.Fatalf("expected every entry to be removed once awaited, got %d", c.Len())
+	}
+
+	// Resolving before awaiting works too, and the first result wins
+	errBoom := errors.New("boom")
+	c.Resolve(-1, "", errBoom)
+	c.Resolve(-1, "Foo", nil)
+	if _, err := c.Await(-1); err != errBoom {
+		t.Fatalf("expected the first result for the key, got %v", err)
+	}
+}
@@ -0,0 +1,135 @@
+package btils
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/goccy/go-json"
+)
+
+// Like 'Unmarshal', but every object key is passed through transform before it's matched against the struct fields
+// of T, e.g. 'SnakeToCamel' to decode snake_case input into untagged structs. Nested objects are transformed too.
+// The input is re-encoded token by token before being decoded, so this is several times slower than 'Unmarshal'
+// with matching struct tags. Prefer tags on hot paths
+func UnmarshalKeyTransform[T any](r io.Reader, transform func(string) string) (*T, error) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber() // Numbers are copied over verbatim, no precision is lost on the way
+
+	var buf bytes.Buffer
+	if err := rewriteKeys(dec, &buf, transform); err != nil {
+		return nil, err
+	}
+	if err := expectEOF(dec); err != nil {
+		return nil, err
+	}
+
+	return Unmarshal[T](&buf)
+}
+
+type rewriteScope struct {
+	object bool
+	n      int
+	// Inside an object, whether the next token is a key
+	key bool
+}
+
+// Copies a single JSON value from dec to w, passing every object key through transform
+func rewriteKeys(dec *json.Decoder, w *bytes.Buffer, transform func(string) string) error {
+	var stack []rewriteScope
+
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return err
+		}
+
+		if delim, ok := tok.(json.Delim); ok && (delim == '}' || delim == ']') {
+			w.WriteByte(byte(delim))
+			stack = stack[:len(stack)-1]
+			if len(stack) == 0 {
+				return nil
+			}
+			continue
+		}
+
+		if len(stack) > 0 {
+			scope := &stack[len(stack)-1]
+			switch {
+			case scope.object && scope.key:
+				key, ok := tok.(string)
+				if !ok {
+					return fmt.Errorf("btils: expected an object key, got %v", tok)
+				}
+				if scope.n > 0 {
+					w.WriteByte(',')
+				}
+				if err := writeJSON(w, transform(key)); err != nil {
+					return err
+				}
+				w.WriteByte(':')
+				scope.key = false
+				continue
+			case scope.object:
+				scope.key = true
+				scope.n++
+			default:
+				if scope.n > 0 {
+					w.WriteByte(',')
+				}
+				scope.n++
+			}
+		}
+
+		switch v := tok.(type) {
+		case json.Delim:
+			w.WriteByte(byte(v))
+			stack = append(stack, rewriteScope{object: v == '{', key: v == '{'})
+			continue
+		case json.Number:
+			w.WriteString(v.String())
+		default:
+			if err := writeJSON(w, v); err != nil {
+				return err
+			}
+		}
+
+		if len(stack) == 0 {
+			return nil
+		}
+	}
+}
+
+func writeJSON(w *bytes.Buffer, v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	w.Write(b)
+	return nil
+}
+
+// Converts snake_case to camelCase, e.g. "user_id" to "userId". Meant as a transform for 'UnmarshalKeyTransform'
+func SnakeToCamel(s string) string {
+	var sb strings.Builder
+	sb.Grow(len(s))
+
+	upper := false
+	for i := 0; i < len(s); i++ {
+		b := s[i]
+		if b == '_' {
+			upper = sb.Len() > 0
+			continue
+		}
+		if upper && b >= 'a' && b <= 'z' {
+			b -= 'a' - 'A'
+		}
+		upper = false
+		sb.WriteByte(b)
+	}
+	return sb.String()
+}
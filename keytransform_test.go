@@ -0,0 +1,76 @@
+package btils
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/goccy/go-json"
+)
+
+func TestUnmarshalKeyTransform(t *testing.T) {
+	type address struct {
+		StreetName string
+		ZipCode    string
+	}
+	type user struct {
+		UserID    json.Number
+		FirstName string
+		IsAdmin   bool
+		Addresses []address
+		Labels    map[string]any
+		Nothing   *string
+	}
+
+	input := `{
+		"user_id": 12345678901234567890,
+		"first_name": "Baloo",
+		"is_admin": true,
+		"addresses": [{"street_name": "Foo", "zip_code": "10115"}, {"street_name": "Baar"}],
+		"labels": {"some_key": [1, "two", {"deep_key": null}]},
+		"nothing": null
+	}`
+
+	u, err := UnmarshalKeyTransform[user](strings.NewReader(input), SnakeToCamel)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if u.UserID != "12345678901234567890" || u.FirstName != "Baloo" || !u.IsAdmin || u.Nothing != nil {
+		t.Fatalf("unexpected result %+v", u)
+	}
+	if len(u.Addresses) != 2 || u.Addresses[0].ZipCode != "10115" || u.Addresses[1].StreetName != "Baar" {
+		t.Fatalf("unexpected nested result %+v", u.Addresses)
+	}
+
+	// Keys of maps are transformed as well
+	nested, ok := u.Labels["someKey"].([]any)
+	if !ok || len(nested) != 3 {
+		t.Fatalf("unexpected labels %v", u.Labels)
+	}
+	if _, ok := nested[2].(map[string]any)["deepKey"]; !ok {
+		t.Fatalf("expected deeply nested keys to be transformed, got %v", nested[2])
+	}
+
+	if _, err := UnmarshalKeyTransform[user](strings.NewReader(`{"user_id": 1`), SnakeToCamel); err == nil {
+		t.Fatal("expected an error for truncated input")
+	}
+	if _, err := UnmarshalKeyTransform[user](strings.NewReader(`{"user_id": 5} {"x":1}`), SnakeToCamel); !errors.Is(err, ErrTrailingData) {
+		t.Fatalf("expected ErrTrailingData for a second value, got %v", err)
+	}
+}
+
+func TestSnakeToCamel(t *testing.T) {
+	for in, expected := range map[string]string{
+		"user_id":   "userId",
+		"first":     "first",
+		"_private":  "private",
+		"double__x": "doubleX",
+		"trailing_": "trailing",
+		"":          "",
+	} {
+		if got := SnakeToCamel(in); got != expected {
+			t.Fatalf("expected %q for %q, got %q", expected, in, got)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package btils
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+)
+
+var ErrWriterClosed = errors.New("btils: writer closed")
+
+// io.Writer that feeds every line written to it into a pool, so logging or piping code can be pointed straight at it
+type LineWriter struct {
+	tm *ThreaderManager[[]byte]
+
+	mu     sync.Mutex
+	buf    []byte
+	closed bool
+}
+
+// Every complete line written is fed to tm as its own item, without the trailing '\n'. Partial lines are buffered
+// until a later Write completes them or 'Close' flushes them. Items are copies, so they stay valid after Write returns
+func NewLineWriter(tm *ThreaderManager[[]byte]) *LineWriter {
+	return &LineWriter{tm: tm}
+}
+
+// Blocks while the pool's queue is full. Returns ErrPoolStopped if the pool has been stopped, in which case n only
+// covers the lines fed before that
+func (w *LineWriter) Write(p []byte) (n int, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return 0, ErrWriterClosed
+	}
+
+	for {
+		i := bytes.IndexByte(p[n:], '\n')
+		if i < 0 {
+			break
+		}
+
+		line := append(append([]byte{}, w.buf...), p[n:n+i]...)
+		if err := w.tm.FeedCtx(context.Background(), line); err != nil {
+			return n, err
+		}
+		w.buf = w.buf[:0]
+		n += i + 1
+	}
+
+	w.buf = append(w.buf, p[n:]...)
+	return len(p), nil
+}
+
+// Feeds the trailing partial line, if any. Writing afterwards returns ErrWriterClosed. Doesn't stop the pool
+func (w *LineWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+
+	if len(w.buf) == 0 {
+		return nil
+	}
+
+	line := w.buf
+	w.buf = nil
+	return w.tm.FeedCtx(context.Background(), line)
+}
@@ -0,0 +1,56 @@
+package btils
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestLineWriter(t *testing.T) {
+	var mu sync.Mutex
+	var lines []string
+	tm := NewThreadManager(1, func(in []byte) {
+		mu.Lock()
+		lines = append(lines, string(in))
+		mu.Unlock()
+	}, WithQueueSize(8))
+
+	tm.Start()
+	defer tm.Stop()
+
+	w := NewLineWriter(tm)
+	for _, chunk := range []string{"Fo", "o\nBa", "ar\n\nBal", "oo\nGo", "lang"} {
+		if n, err := w.Write([]byte(chunk)); err != nil || n != len(chunk) {
+			t.Fatalf("unexpected write result %d %v", n, err)
+		}
+	}
+
+	tm.Wait()
+	if !SliceEqual(lines, []string{"Foo", "Baar", "", "Baloo"}) {
+		t.Fatalf("expected fragmented lines to be reassembled, got %q", lines)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	tm.Wait()
+	if !SliceEqual(lines, []string{"Foo", "Baar", "", "Baloo", "Golang"}) {
+		t.Fatalf("expected Close to feed the partial line, got %q", lines)
+	}
+
+	if _, err := w.Write([]byte("Foo\n")); !errors.Is(err, ErrWriterClosed) {
+		t.Fatalf("expected ErrWriterClosed, got %v", err)
+	}
+}
+
+func TestLineWriterStoppedPool(t *testing.T) {
+	tm := NewThreadManager(1, func(in []byte) {})
+	tm.Start()
+	tm.CloseAndDrain()
+
+	_, err := fmt.Fprint(NewLineWriter(tm), "Foo\n")
+	if !errors.Is(err, ErrPoolStopped) {
+		t.Fatalf("expected ErrPoolStopped, got %v", err)
+	}
+}
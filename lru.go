@@ -0,0 +1,80 @@
+package btils
+
+import (
+	"container/list"
+	"sync"
+)
+
+// Goroutine-safe least-recently-used cache holding at most capacity entries. Get and Put are O(1)
+type LRU[K comparable, V any] struct {
+	mu       sync.Mutex
+	capacity int
+	entries  map[K]*list.Element
+	order    list.List // Front is the most recently used entry
+
+	onEvict func(key K, value V)
+}
+
+type lruEntry[K comparable, V any] struct {
+	key   K
+	value V
+}
+
+// onEvict is optional and called outside of the cache's lock whenever an entry is evicted to make room,
+// so it can be used to release resources held by the value
+func NewLRU[K comparable, V any](capacity int, onEvict func(key K, value V)) *LRU[K, V] {
+	return &LRU[K, V]{
+		capacity: max(capacity, 1),
+		entries:  make(map[K]*list.Element, capacity),
+		onEvict:  onEvict,
+	}
+}
+
+// Returns the value stored for key and marks it as most recently used
+func (c *LRU[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return None[V](), false
+	}
+
+	c.order.MoveToFront(elem)
+	return elem.Value.(*lruEntry[K, V]).value, true
+}
+
+// Stores value for key, evicting the least recently used entry if the cache is full
+func (c *LRU[K, V]) Put(key K, value V) {
+	c.mu.Lock()
+
+	if elem, ok := c.entries[key]; ok {
+		elem.Value.(*lruEntry[K, V]).value = value
+		c.order.MoveToFront(elem)
+		c.mu.Unlock()
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&lruEntry[K, V]{key: key, value: value})
+
+	var evicted *lruEntry[K, V]
+	if c.order.Len() > c.capacity {
+		back := c.order.Back()
+		c.order.Remove(back)
+		evicted = back.Value.(*lruEntry[K, V])
+		delete(c.entries, evicted.key)
+	}
+
+	c.mu.Unlock()
+
+	if evicted != nil && c.onEvict != nil {
+		c.onEvict(evicted.key, evicted.value)
+	}
+}
+
+func (c *LRU[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.order.Len()
+}
@@ -0,0 +1,58 @@
+package btils
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestLRUEviction(t *testing.T) {
+	var evicted []string
+	c := NewLRU[string, int](2, func(key string, value int) {
+		evicted = append(evicted, key)
+	})
+
+	c.Put("Foo", 1)
+	c.Put("Baar", 2)
+	c.Get("Foo") // Baar is now the least recently used
+	c.Put("Baloo", 3)
+
+	if len(evicted) != 1 || evicted[0] != "Baar" {
+		t.Fatalf("expected Baar to be evicted, got %v", evicted)
+	}
+	if _, ok := c.Get("Baar"); ok {
+		t.Fatal("expected Baar to be gone")
+	}
+	if v, ok := c.Get("Foo"); !ok || v != 1 {
+		t.Fatalf("expected Foo=1, got %d %v", v, ok)
+	}
+
+	// Updating an existing key must not evict anything
+	c.Put("Baloo", 4)
+	if c.Len() != 2 || len(evicted) != 1 {
+		t.Fatalf("unexpected state after update, len %d evicted %v", c.Len(), evicted)
+	}
+	if v, _ := c.Get("Baloo"); v != 4 {
+		t.Fatalf("expected Baloo=4, got %d", v)
+	}
+}
+
+func TestLRUConcurrent(t *testing.T) {
+	c := NewLRU[int, int](64, nil)
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				c.Put((g*i)%128, i)
+				c.Get(i % 128)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if c.Len() > 64 {
+		t.Fatalf("cache exceeded its capacity: %d", c.Len())
+	}
+}
@@ -0,0 +1,57 @@
+package btils
+
+// Indexes every element of s by the key keyFn derives from it. Later duplicates overwrite earlier ones
+func ToMap[T any, K comparable](s []T, keyFn func(T) K) map[K]T {
+	res := make(map[K]T, len(s))
+	for _, v := range s {
+		res[keyFn(v)] = v
+	}
+	return res
+}
+
+// Like 'ToMap', but the value stored for each element is derived by valueFn
+func ToMapFunc[T any, K comparable, V any](s []T, keyFn func(T) K, valueFn func(T) V) map[K]V {
+	res := make(map[K]V, len(s))
+	for _, v := range s {
+		res[keyFn(v)] = valueFn(v)
+	}
+	return res
+}
+
+// Reports whether a and b hold the same keys with equal values. A nil and an empty map are equal, unlike with
+// reflect.DeepEqual
+func MapEqual[K, V comparable](a, b map[K]V) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, va := range a {
+		if vb, ok := b[k]; !ok || va != vb {
+			return false
+		}
+	}
+	return true
+}
+
+// Key-value pair of a map, see 'Entries'
+type Entry[K comparable, V any] struct {
+	Key   K
+	Value V
+}
+
+// Flattens m into its key-value pairs. The order is unspecified, sort the result if it has to be deterministic
+func Entries[K comparable, V any](m map[K]V) []Entry[K, V] {
+	res := make([]Entry[K, V], 0, len(m))
+	for k, v := range m {
+		res = append(res, Entry[K, V]{Key: k, Value: v})
+	}
+	return res
+}
+
+// Rebuilds a map from its key-value pairs. Later duplicates overwrite earlier ones
+func FromEntries[K comparable, V any](entries []Entry[K, V]) map[K]V {
+	res := make(map[K]V, len(entries))
+	for _, e := range entries {
+		res[e.Key] = e.Value
+	}
+	return res
+}
@@ -0,0 +1,85 @@
+package btils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestToMap(t *testing.T) {
+	people := []testPerson{{Name: "Alice", Age: 30}, {Name: "Bob", Age: 25}, {Name: "Alice", Age: 31}}
+	name := func(p testPerson) string { return p.Name }
+
+	byName := ToMap(people, name)
+	if len(byName) != 2 || byName["Alice"].Age != 31 || byName["Bob"].Age != 25 {
+		t.Fatalf("unexpected map %v", byName)
+	}
+
+	ages := ToMapFunc(people, name, func(p testPerson) int { return p.Age })
+	if len(ages) != 2 || ages["Alice"] != 31 {
+		t.Fatalf("unexpected map %v", ages)
+	}
+
+	if m := ToMap(nil, name); m == nil || len(m) != 0 {
+		t.Fatalf("expected an empty map for nil input, got %#v", m)
+	}
+}
+
+func TestMapEqual(t *testing.T) {
+	a := map[string]int{"Foo": 1, "Baar": 0}
+
+	if !MapEqual(a, map[string]int{"Baar": 0, "Foo": 1}) {
+		t.Fatal("expected equal maps to be equal")
+	}
+	if MapEqual(a, map[string]int{"Foo": 1, "Baloo": 0}) {
+		t.Fatal("expected a missing key to not be mistaken for a zero value")
+	}
+	if MapEqual(a, map[string]int{"Foo": 2, "Baar": 0}) {
+		t.Fatal("expected different values to not be equal")
+	}
+	if !MapEqual(nil, map[string]int{}) {
+		t.Fatal("expected nil and empty maps to be equal")
+	}
+}
+
+func BenchmarkMapEqual(b *testing.B) {
+	x := ToMap(Times(1024, func(i int) int { return i }), func(i int) int { return i })
+	y := ToMap(Times(1024, func(i int) int { return i }), func(i int) int { return i })
+
+	b.Run("MapEqual", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			MapEqual(x, y)
+		}
+	})
+	b.Run("DeepEqual", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			reflect.DeepEqual(x, y)
+		}
+	})
+}
+
+func TestEntries(t *testing.T) {
+	m := map[string]int{"Foo": 1, "Baar": 2, "Baloo": 0}
+
+	entries := Entries(m)
+	if len(entries) != len(m) {
+		t.Fatalf("expected %d entries, got %v", len(m), entries)
+	}
+	for _, e := range entries {
+		if v, ok := m[e.Key]; !ok || v != e.Value {
+			t.Fatalf("unexpected entry %+v", e)
+		}
+	}
+
+	if res := FromEntries(entries); !MapEqual(res, m) {
+		t.Fatalf("expected the round trip to keep the map, got %v", res)
+	}
+
+	dup := FromEntries([]Entry[string, int]{{"Foo", 1}, {"Foo", 2}})
+	if len(dup) != 1 || dup["Foo"] != 2 {
+		t.Fatalf("expected the later duplicate to win, got %v", dup)
+	}
+
+	if e := Entries[string, int](nil); e == nil || len(e) != 0 {
+		t.Fatalf("expected an empty slice for a nil map, got %#v", e)
+	}
+}
@@ -0,0 +1,57 @@
+package btils
+
+import (
+	"bytes"
+
+	"github.com/goccy/go-json"
+)
+
+// Applies a JSON Merge Patch (RFC 7386) to original: objects are merged recursively, null removes a key and
+// everything else, including arrays, replaces the original value. A patch that isn't an object replaces the whole
+// document. Numbers are kept verbatim and the result has its object keys sorted. An empty original is treated as null
+func MergePatch(original, patch []byte) ([]byte, error) {
+	var doc any
+	if len(bytes.TrimSpace(original)) > 0 {
+		if err := decodeNumbers(original, &doc); err != nil {
+			return nil, err
+		}
+	}
+
+	var p any
+	if err := decodeNumbers(patch, &p); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(mergePatch(doc, p))
+}
+
+func decodeNumbers(b []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	return expectEOF(dec)
+}
+
+func mergePatch(doc, patch any) any {
+	p, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+
+	target, ok := doc.(map[string]any)
+	if !ok {
+		target = make(map[string]any, len(p))
+	}
+
+	for k, v := range p {
+		if v == nil {
+			delete(target, k)
+			continue
+		}
+		target[k] = mergePatch(target[k], v)
+	}
+
+	return target
+}
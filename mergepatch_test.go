@@ -0,0 +1,45 @@
+package btils
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMergePatch(t *testing.T) {
+	for _, tc := range []struct {
+		name, original, patch, expected string
+	}{
+		{"replace scalar", `{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{"add key", `{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{"null deletes", `{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{"null for missing key", `{"a":"b"}`, `{"x":null}`, `{"a":"b"}`},
+		{"nested merge", `{"a":{"b":"c","d":1}}`, `{"a":{"b":"x","d":null,"e":[1]}}`, `{"a":{"b":"x","e":[1]}}`},
+		{"arrays replace", `{"a":[1,2,3]}`, `{"a":[4]}`, `{"a":[4]}`},
+		{"object replaces scalar", `{"a":"b"}`, `{"a":{"c":null,"d":1}}`, `{"a":{"d":1}}`},
+		{"non-object patch replaces", `{"a":"b"}`, `["c"]`, `["c"]`},
+		{"non-object original", `["a"]`, `{"a":"b"}`, `{"a":"b"}`},
+		{"empty original", ``, `{"a":"b"}`, `{"a":"b"}`},
+		{"big numbers survive", `{"a":12345678901234567890}`, `{"b":0.10000000000000000001}`, `{"a":12345678901234567890,"b":0.10000000000000000001}`},
+	} {
+		got, err := MergePatch([]byte(tc.original), []byte(tc.patch))
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if string(got) != tc.expected {
+			t.Fatalf("%s: expected %s, got %s", tc.name, tc.expected, got)
+		}
+	}
+
+	if _, err := MergePatch([]byte(`{"a":`), []byte(`{}`)); err == nil {
+		t.Fatal("expected an error for a malformed original")
+	}
+	if _, err := MergePatch([]byte(`{}`), []byte(`{`)); err == nil {
+		t.Fatal("expected an error for a malformed patch")
+	}
+	if _, err := MergePatch([]byte(`{"a":1}`), []byte(`{"b":2} garbage`)); !errors.Is(err, ErrTrailingData) {
+		t.Fatalf("expected ErrTrailingData for trailing data in the patch, got %v", err)
+	}
+	if _, err := MergePatch([]byte(`{"a":1} {}`), []byte(`{"b":2}`)); !errors.Is(err, ErrTrailingData) {
+		t.Fatalf("expected ErrTrailingData for trailing data in the original, got %v", err)
+	}
+}
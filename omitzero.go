@@ -0,0 +1,143 @@
+package btils
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/goccy/go-json"
+)
+
+var (
+	marshalerType = reflect.TypeFor[json.Marshaler]()
+	rawType       = reflect.TypeFor[json.RawMessage]()
+)
+
+// Like json.Marshal, but struct fields holding their zero value are dropped as if they were tagged omitempty,
+// regardless of their actual tags. Applies to nested structs as well, including those inside slices and maps, but
+// slice elements and map entries themselves are always kept. Values implementing json.Marshaler (e.g. time.Time)
+// are encoded by their marshaler and only dropped if they are zero as a whole.
+// This walks the value with reflection instead of using goccy's cached encoders, so expect it to be several times
+// slower than 'json.Marshal'. Meant for compact wire formats, not hot paths
+func MarshalOmitZero[T any](v T) ([]byte, error) {
+	return marshalOmitZero(reflect.ValueOf(&v).Elem())
+}
+
+func marshalOmitZero(v reflect.Value) ([]byte, error) {
+	if v.Type().Implements(marshalerType) || (v.CanAddr() && v.Addr().Type().Implements(marshalerType)) {
+		return json.Marshal(v.Interface())
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return []byte("null"), nil
+		}
+		return marshalOmitZero(v.Elem())
+	case reflect.Struct:
+		return marshalStructOmitZero(v)
+	case reflect.Slice, reflect.Array:
+		// []byte is encoded as base64, nothing inside it to omit
+		if v.Type().Elem().Kind() == reflect.Uint8 || (v.Kind() == reflect.Slice && v.IsNil()) {
+			return json.Marshal(v.Interface())
+		}
+
+		elems := make([]json.RawMessage, v.Len())
+		for i := range elems {
+			b, err := marshalOmitZero(v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			elems[i] = b
+		}
+		return json.Marshal(elems)
+	case reflect.Map:
+		if v.IsNil() {
+			return []byte("null"), nil
+		}
+
+		// Let json.Marshal take care of encoding and sorting the keys
+		res := reflect.MakeMapWithSize(reflect.MapOf(v.Type().Key(), rawType), v.Len())
+		for iter := v.MapRange(); iter.Next(); {
+			b, err := marshalOmitZero(iter.Value())
+			if err != nil {
+				return nil, err
+			}
+			res.SetMapIndex(iter.Key(), reflect.ValueOf(json.RawMessage(b)))
+		}
+		return json.Marshal(res.Interface())
+	}
+
+	return json.Marshal(v.Interface())
+}
+
+func marshalStructOmitZero(v reflect.Value) ([]byte, error) {
+	var sb strings.Builder
+	sb.WriteByte('{')
+
+	first := true
+	if err := writeFieldsOmitZero(&sb, v, &first); err != nil {
+		return nil, err
+	}
+
+	sb.WriteByte('}')
+	return []byte(sb.String()), nil
+}
+
+func writeFieldsOmitZero(sb *strings.Builder, v reflect.Value, first *bool) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name, _, _ := strings.Cut(tag, ",")
+
+		// Untagged embedded structs are flattened into the parent, like encoding/json does
+		if field.Anonymous && name == "" {
+			fv := v.Field(i)
+			if fv.Kind() == reflect.Pointer {
+				if fv.IsNil() {
+					continue
+				}
+				fv = fv.Elem()
+			}
+			if fv.Kind() == reflect.Struct {
+				if err := writeFieldsOmitZero(sb, fv, first); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+
+		if !field.IsExported() || v.Field(i).IsZero() {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		b, err := marshalOmitZero(v.Field(i))
+		if err != nil {
+			return err
+		}
+
+		key, err := json.Marshal(name)
+		if err != nil {
+			return err
+		}
+
+		if !*first {
+			sb.WriteByte(',')
+		}
+		*first = false
+
+		sb.Write(key)
+		sb.WriteByte(':')
+		sb.Write(b)
+	}
+
+	return nil
+}
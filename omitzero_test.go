@@ -0,0 +1,61 @@
+package btils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMarshalOmitZero(t *testing.T) {
+	type address struct {
+		City string `json:"city"`
+		Zip  string `json:"zip"`
+	}
+	type event struct {
+		Name    string            `json:"name"`
+		Count   int               `json:"count"`
+		Enabled bool              `json:"enabled"`
+		Skipped string            `json:"-"`
+		Address address           `json:"address"`
+		Tags    []address         `json:"tags"`
+		Labels  map[string]int    `json:"labels"`
+		At      time.Time         `json:"at"`
+		Ptr     *address          `json:"ptr"`
+		Extra   map[string]string `json:"extra"`
+		testPerson
+	}
+
+	b, err := MarshalOmitZero(event{
+		Name:       "Foo",
+		Count:      0, // Explicitly zero, still dropped
+		Skipped:    "Baar",
+		Address:    address{City: "Berlin"},
+		Tags:       []address{{Zip: "10115"}, {}},
+		Labels:     map[string]int{"b": 0, "a": 1},
+		Ptr:        &address{},
+		testPerson: testPerson{Age: 30},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{"name":"Foo","address":{"city":"Berlin"},"tags":[{"zip":"10115"},{}],"labels":{"a":1,"b":0},"ptr":{},"age":30}`
+	if string(b) != expected {
+		t.Fatalf("expected %s, got %s", expected, b)
+	}
+
+	b, err = MarshalOmitZero(event{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "{}" {
+		t.Fatalf("expected an empty object, got %s", b)
+	}
+
+	b, err = MarshalOmitZero([]int{0, 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "[0,1]" {
+		t.Fatalf("expected non-struct values to be encoded as-is, got %s", b)
+	}
+}
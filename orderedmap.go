@@ -0,0 +1,156 @@
+package btils
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/goccy/go-json"
+)
+
+// Map that remembers the order keys were first set in, e.g. for serializing configuration to JSON deterministically.
+// Not goroutine-safe
+type OrderedMap[K comparable, V any] struct {
+	keys   []K
+	values map[K]V
+}
+
+func NewOrderedMap[K comparable, V any]() *OrderedMap[K, V] {
+	return &OrderedMap[K, V]{
+		values: make(map[K]V),
+	}
+}
+
+// Re-setting an existing key updates its value but keeps its position
+func (m *OrderedMap[K, V]) Set(key K, value V) {
+	if m.values == nil {
+		m.values = make(map[K]V)
+	}
+
+	if _, ok := m.values[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = value
+}
+
+func (m *OrderedMap[K, V]) Get(key K) (V, bool) {
+	v, ok := m.values[key]
+	return v, ok
+}
+
+// O(n), since the key has to be removed from the insertion order as well
+func (m *OrderedMap[K, V]) Delete(key K) {
+	if _, ok := m.values[key]; !ok {
+		return
+	}
+
+	delete(m.values, key)
+	for i, k := range m.keys {
+		if k == key {
+			m.keys = append(m.keys[:i], m.keys[i+1:]...)
+			break
+		}
+	}
+}
+
+// Keys in insertion order. The returned slice is a copy
+func (m *OrderedMap[K, V]) Keys() []K {
+	return append([]K(nil), m.keys...)
+}
+
+func (m *OrderedMap[K, V]) Len() int {
+	return len(m.keys)
+}
+
+// Emits the keys in insertion order. Non-string keys, e.g. integers, are quoted like encoding/json does for maps
+func (m *OrderedMap[K, V]) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+
+	for i, key := range m.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		kb, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		if len(kb) == 0 || kb[0] != '"' {
+			kb, err = json.Marshal(string(kb))
+			if err != nil {
+				return nil, err
+			}
+		}
+		buf.Write(kb)
+		buf.WriteByte(':')
+
+		vb, err := json.Marshal(m.values[key])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(vb)
+	}
+
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// Replaces the contents of the map, keeping the key order of the document
+func (m *OrderedMap[K, V]) UnmarshalJSON(b []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(b))
+
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		return fmt.Errorf("btils: expected a JSON object, got %v", tok)
+	}
+
+	m.keys = nil
+	m.values = make(map[K]V)
+
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+
+		name, ok := tok.(string)
+		if !ok {
+			return fmt.Errorf("btils: expected an object key, got %v", tok)
+		}
+
+		key, err := unmarshalMapKey[K](name)
+		if err != nil {
+			return err
+		}
+
+		var value V
+		if err := dec.Decode(&value); err != nil {
+			return err
+		}
+		m.Set(key, value)
+	}
+
+	_, err = dec.Token()
+	return err
+}
+
+// Reverses the key quoting of 'MarshalJSON'
+func unmarshalMapKey[K comparable](name string) (K, error) {
+	var key K
+
+	quoted, err := json.Marshal(name)
+	if err != nil {
+		return key, err
+	}
+	if json.Unmarshal(quoted, &key) == nil {
+		return key, nil
+	}
+
+	if err := json.Unmarshal([]byte(name), &key); err != nil {
+		return key, fmt.Errorf("btils: can't decode object key %q: %w", name, err)
+	}
+	return key, nil
+}
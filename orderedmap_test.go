@@ -0,0 +1,58 @@
+package btils
+
+import "testing"
+
+func TestOrderedMapJSON(t *testing.T) {
+	m := NewOrderedMap[string, int]()
+	m.Set("zeta", 1)
+	m.Set("alpha", 2)
+	m.Set("mid", 3)
+	m.Set("zeta", 4) // Must keep its position
+	m.Delete("mid")
+
+	b, err := m.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `{"zeta":4,"alpha":2}` {
+		t.Fatalf("unexpected JSON %s", b)
+	}
+
+	var decoded OrderedMap[string, int]
+	if err := decoded.UnmarshalJSON(b); err != nil {
+		t.Fatal(err)
+	}
+	again, err := decoded.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(again) != string(b) {
+		t.Fatalf("round trip changed the document: %s != %s", again, b)
+	}
+
+	if keys := decoded.Keys(); len(keys) != 2 || keys[0] != "zeta" || keys[1] != "alpha" {
+		t.Fatalf("unexpected keys %v", keys)
+	}
+}
+
+func TestOrderedMapIntKeys(t *testing.T) {
+	m := NewOrderedMap[int, string]()
+	m.Set(3, "Foo")
+	m.Set(1, "Baar")
+
+	b, err := m.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `{"3":"Foo","1":"Baar"}` {
+		t.Fatalf("unexpected JSON %s", b)
+	}
+
+	decoded := NewOrderedMap[int, string]()
+	if err := decoded.UnmarshalJSON(b); err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := decoded.Get(3); !ok || v != "Foo" || decoded.Keys()[0] != 3 {
+		t.Fatalf("unexpected decoded map %v", decoded.Keys())
+	}
+}
@@ -0,0 +1,97 @@
+package btils
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+// Splits [0, n) into up to workers contiguous, disjoint ranges of nearly equal size and calls fn for each of them
+// in its own goroutine, returning once all of them are done. Lower overhead than feeding single indices to a pool,
+// as long as the work is index-addressable. fn is never called with an empty range
+func ParallelRange(n, workers int, fn func(start, end int)) {
+	parallelRange(n, workers, func(_, start, end int) {
+		fn(start, end)
+	})
+}
+
+// Like 'ParallelRange', but fn also receives the index of its range
+func parallelRange(n, workers int, fn func(i, start, end int)) {
+	if n <= 0 {
+		return
+	}
+
+	workers = min(max(workers, 1), n)
+	size := n / workers
+	rest := n % workers
+
+	var wg sync.WaitGroup
+	start := 0
+	for i := 0; i < workers; i++ {
+		end := start + size
+		if i < rest {
+			end++
+		}
+
+		wg.Add(1)
+		go func(i, start, end int) {
+			defer wg.Done()
+			fn(i, start, end)
+		}(i, start, end)
+
+		start = end
+	}
+	wg.Wait()
+}
+
+// Splits s into up to workers contiguous partitions, reduces each one in its own goroutine starting from identity
+// and then combines the partial results in partition order. reduce and combine must be associative and identity
+// must be neutral for combine, otherwise the result depends on the number of workers
+func ParallelReduce[T, U any](s []T, workers int, identity U, reduce func(U, T) U, combine func(U, U) U) U {
+	partials := make([]U, min(max(workers, 1), max(len(s), 1)))
+	for i := range partials {
+		partials[i] = identity
+	}
+
+	parallelRange(len(s), workers, func(i, start, end int) {
+		acc := identity
+		for _, v := range s[start:end] {
+			acc = reduce(acc, v)
+		}
+		partials[i] = acc
+	})
+
+	res := identity
+	for _, partial := range partials {
+		res = combine(res, partial)
+	}
+	return res
+}
+
+// Runs fn for every item on a temporary pool of workers and returns once all of them are done. Every error is kept,
+// joined in input order and prefixed with its item's index, and each one wraps an *ItemError holding the item.
+// There's no early cancellation, a failing item doesn't stop the others. workers below 1 uses GOMAXPROCS
+func RunAll[T any](items []T, workers int, fn func(T) error) error {
+	if len(items) == 0 {
+		return nil
+	}
+	if workers < 1 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	errs := make([]error, len(items))
+	tm := NewThreadManager[int](min(workers, len(items)), func(i int) {
+		if err := fn(items[i]); err != nil {
+			errs[i] = fmt.Errorf("btils: item %d: %w", i, &ItemError[T]{Item: items[i], Err: err})
+		}
+	})
+
+	tm.Start()
+	for i := range items {
+		tm.Feed(i)
+	}
+	tm.CloseAndDrain()
+
+	return errors.Join(errs...)
+}
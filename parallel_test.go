@@ -0,0 +1,106 @@
+package btils
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestParallelReduce(t *testing.T) {
+	s := make([]int, 1001)
+	for i := range s {
+		s[i] = i
+	}
+
+	add := func(a, b int) int { return a + b }
+	for _, workers := range []int{0, 1, 3, 8, 2000} {
+		if sum := ParallelReduce(s, workers, 0, add, add); sum != 500500 {
+			t.Fatalf("expected 500500 with %d workers, got %d", workers, sum)
+		}
+	}
+
+	// String concatenation is associative but not commutative, so this also checks the combine order
+	concat := func(acc string, v int) string { return acc + strconv.Itoa(v) }
+	sequential := ""
+	for _, v := range s[:50] {
+		sequential = concat(sequential, v)
+	}
+	if res := ParallelReduce(s[:50], 7, "", concat, func(a, b string) string { return a + b }); res != sequential {
+		t.Fatalf("expected %s, got %s", sequential, res)
+	}
+
+	if sum := ParallelReduce(nil, 4, 0, add, add); sum != 0 {
+		t.Fatalf("expected identity for empty input, got %d", sum)
+	}
+}
+
+func TestParallelRange(t *testing.T) {
+	for _, n := range []int{0, 1, 7, 100, 1001} {
+		for _, workers := range []int{0, 1, 3, 8, 2000} {
+			covered := make([]int, n)
+
+			var mu sync.Mutex
+			ranges := 0
+			ParallelRange(n, workers, func(start, end int) {
+				if start >= end {
+					t.Errorf("empty range [%d, %d)", start, end)
+				}
+				for i := start; i < end; i++ {
+					covered[i]++
+				}
+
+				mu.Lock()
+				ranges++
+				mu.Unlock()
+			})
+
+			for i, c := range covered {
+				if c != 1 {
+					t.Fatalf("n=%d workers=%d: index %d covered %d times", n, workers, i, c)
+				}
+			}
+			if ranges > max(workers, 1) {
+				t.Fatalf("n=%d workers=%d: expected at most %d ranges, got %d", n, workers, max(workers, 1), ranges)
+			}
+		}
+	}
+}
+
+func TestRunAll(t *testing.T) {
+	errBoom := errors.New("boom")
+	items := []string{"Foo", "Baar", "Baloo", "Golang"}
+
+	var mu sync.Mutex
+	handled := 0
+	err := RunAll(items, 2, func(s string) error {
+		mu.Lock()
+		handled++
+		mu.Unlock()
+
+		if strings.HasPrefix(s, "Ba") {
+			return errBoom
+		}
+		return nil
+	})
+
+	if handled != 4 {
+		t.Fatalf("expected every item to run despite failures, got %d", handled)
+	}
+	if !errors.Is(err, errBoom) || !strings.Contains(err.Error(), "item 1") || !strings.Contains(err.Error(), "item 2") {
+		t.Fatalf("expected errors for items 1 and 2, got %v", err)
+	}
+
+	var itemErr *ItemError[string]
+	if !errors.As(err, &itemErr) || itemErr.Item != "Baar" {
+		t.Fatalf("expected the first failing item to be available, got %v", itemErr)
+	}
+
+	if err := RunAll(nil, 4, func(int) error { return errBoom }); err != nil {
+		t.Fatalf("expected nil for no items, got %v", err)
+	}
+	if err := RunAll([]int{1, 2, 3}, 0, func(int) error { return nil }); err != nil {
+		t.Fatalf("expected 0 workers to fall back to GOMAXPROCS, got %v", err)
+	}
+}
@@ -0,0 +1,58 @@
+package btils
+
+import "sync"
+
+// Runs fn over everything received from in using workers goroutines. The returned channel is unbuffered, so a slow
+// consumer backpressures the workers, and it's closed once in is closed and every worker has finished.
+// With more than one worker the output order doesn't match the input order
+func Stage[In, Out any](in <-chan In, workers int, fn func(In) Out) <-chan Out {
+	out := make(chan Out)
+
+	var wg sync.WaitGroup
+	for i := 0; i < max(workers, 1); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for v := range in {
+				out <- fn(v)
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+
+	return out
+}
+
+// Chain of 'Stage's turning In into Out. Build it with 'NewPipeline' and extend it with 'AddStage',
+// every stage's output feeds the next stage's input
+type Pipeline[In, Out any] struct {
+	connect func(src <-chan In) <-chan Out
+}
+
+func NewPipeline[In, Out any](workers int, fn func(In) Out) *Pipeline[In, Out] {
+	return &Pipeline[In, Out]{
+		connect: func(src <-chan In) <-chan Out {
+			return Stage(src, workers, fn)
+		},
+	}
+}
+
+// Returns a new pipeline with an additional stage appended to p. A function rather than a method, since methods
+// can't introduce the new Out type parameter
+func AddStage[In, Mid, Out any](p *Pipeline[In, Mid], workers int, fn func(Mid) Out) *Pipeline[In, Out] {
+	return &Pipeline[In, Out]{
+		connect: func(src <-chan In) <-chan Out {
+			return Stage(p.connect(src), workers, fn)
+		},
+	}
+}
+
+// Connects all stages to src and returns the final output, which is closed once src is closed and everything has
+// flowed through
+func (p *Pipeline[In, Out]) Run(src <-chan In) <-chan Out {
+	return p.connect(src)
+}
@@ -0,0 +1,37 @@
+package btils
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestPipeline(t *testing.T) {
+	double := NewPipeline(3, func(in int) int {
+		return in * 2
+	})
+	p := AddStage(double, 2, func(in int) string {
+		return strconv.Itoa(in)
+	})
+
+	src := make(chan int)
+	go func() {
+		for i := 0; i < 100; i++ {
+			src <- i
+		}
+		close(src)
+	}()
+
+	seen := map[string]bool{}
+	for out := range p.Run(src) {
+		seen[out] = true
+	}
+
+	if len(seen) != 100 {
+		t.Fatalf("expected 100 distinct outputs, got %d", len(seen))
+	}
+	for i := 0; i < 100; i++ {
+		if !seen[strconv.Itoa(i*2)] {
+			t.Fatalf("missing output for %d", i)
+		}
+	}
+}
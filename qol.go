@@ -10,3 +10,45 @@ func If[T any](cond bool, trueVal, falseVal T) T {
 	}
 	return falseVal
 }
+
+// Returns the first non-nil pointer, or nil if all of them are nil
+func CoalescePtr[T any](ptrs ...*T) *T {
+	for _, p := range ptrs {
+		if p != nil {
+			return p
+		}
+	}
+	return nil
+}
+
+// Dereferences the first non-nil pointer, or returns fallback if all of them are nil
+func FirstNonNilValue[T any](fallback T, ptrs ...*T) T {
+	if p := CoalescePtr(ptrs...); p != nil {
+		return *p
+	}
+	return fallback
+}
+
+// Any integer or floating point type
+type Number interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr |
+		~float32 | ~float64
+}
+
+// Chains f and g into a single func, f runs first. Handy for assembling pool callbacks from smaller pieces
+func Compose2[A, B, C any](f func(A) B, g func(B) C) func(A) C {
+	return func(a A) C {
+		return g(f(a))
+	}
+}
+
+// Chains fns into a single func, applied left to right. Without any fns the input is returned as is
+func Pipe[T any](fns ...func(T) T) func(T) T {
+	return func(in T) T {
+		for _, fn := range fns {
+			in = fn(in)
+		}
+		return in
+	}
+}
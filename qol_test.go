@@ -0,0 +1,55 @@
+package btils
+
+import "testing"
+
+func TestCoalescePtr(t *testing.T) {
+	a, b := 1, 2
+
+	if CoalescePtr[int](nil, nil) != nil || CoalescePtr[int]() != nil {
+		t.Fatal("expected nil when every pointer is nil")
+	}
+	if CoalescePtr(nil, &a, &b) != &a {
+		t.Fatal("expected the first non-nil pointer")
+	}
+	if CoalescePtr(&b) != &b {
+		t.Fatal("expected the single pointer")
+	}
+
+	if v := FirstNonNilValue(5, nil, &b); v != 2 {
+		t.Fatalf("expected 2, got %d", v)
+	}
+	if v := FirstNonNilValue[int](5, nil); v != 5 {
+		t.Fatalf("expected the fallback, got %d", v)
+	}
+
+	if allocs := testing.AllocsPerRun(100, func() { CoalescePtr(nil, &a) }); allocs != 0 {
+		t.Fatalf("expected no allocations, got %f", allocs)
+	}
+}
+
+func TestCompose2(t *testing.T) {
+	length := Compose2(func(s string) string { return s + "Baar" }, func(s string) int { return len(s) })
+	if n := length("Foo"); n != 7 {
+		t.Fatalf("expected 7, got %d", n)
+	}
+
+	describe := Compose2(func(n int) int { return n * 2 }, func(n int) string { return If(n > 5, "big", "small") })
+	if s := describe(3); s != "big" {
+		t.Fatalf("expected the first func to run first, got %q", s)
+	}
+}
+
+func TestPipe(t *testing.T) {
+	add := func(n int) func(int) int { return func(in int) int { return in + n } }
+	double := func(in int) int { return in * 2 }
+
+	if v := Pipe(add(1), double)(3); v != 8 {
+		t.Fatalf("expected left to right application, got %d", v)
+	}
+	if v := Pipe(double, add(1))(3); v != 7 {
+		t.Fatalf("expected left to right application, got %d", v)
+	}
+	if v := Pipe[int]()(3); v != 3 {
+		t.Fatalf("expected the identity without fns, got %d", v)
+	}
+}
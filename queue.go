@@ -0,0 +1,311 @@
+package btils
+
+import (
+	"errors"
+	"sync"
+)
+
+var (
+	ErrQueueClosed   = errors.New("btils: queue closed")
+	errQueueCanceled = errors.New("btils: queue wait canceled")
+	errQueueEmpty    = errors.New("btils: queue empty")
+	errQueueFull     = errors.New("btils: queue full")
+)
+
+// Fixed-size FIFO ring buffer guarded by a mutex. Unlike a channel it can be inspected without consuming anything
+type queue[T any] struct {
+	mu    sync.Mutex
+	items []T
+	head  int
+	size  int
+
+	closed bool
+	// Hand out the newest item first instead of the oldest. Has to be set before the queue is used
+	lifo bool
+
+	notEmpty signal
+	notFull  signal
+}
+
+// Wakes up goroutines waiting for an item or for room. Waits that can't be canceled, the common case, use the
+// cond, which wakes a single waiter without allocating. Cancelable ones need something to select on and park on ch
+// instead, which is only allocated while one of them is actually parked
+type signal struct {
+	cond sync.Cond
+	ch   chan struct{}
+	// Parked goroutines, cond and ch ones alike. Waking is a no-op while it's 0
+	waiters int
+}
+
+func newQueue[T any](capacity int) *queue[T] {
+	// A channel with capacity 0 still hands items over, a ring buffer with capacity 0 would block forever
+	if capacity < 1 {
+		capacity = 1
+	}
+
+	q := &queue[T]{
+		items: make([]T, capacity),
+	}
+	q.notEmpty.cond.L = &q.mu
+	q.notFull.cond.L = &q.mu
+	return q
+}
+
+// Has to be called with the queue's lock held, which is released while parked and re-acquired before returning.
+// Returns false if done fired before s was woken, a nil done never fires
+func (s *signal) park(done <-chan struct{}) bool {
+	if done == nil {
+		s.waiters++
+		s.cond.Wait()
+		s.waiters--
+		return true
+	}
+
+	if s.ch == nil {
+		s.ch = make(chan struct{})
+	}
+	ch := s.ch
+	s.waiters++
+	s.cond.L.Unlock()
+
+	woken := true
+	select {
+	case <-ch:
+	case <-done:
+		woken = false
+	}
+
+	s.cond.L.Lock()
+	s.waiters--
+	if !woken && s.waiters == 0 && s.ch == ch {
+		s.ch = nil // Nobody left to wake up
+	}
+	return woken
+}
+
+// Has to be called with the queue's lock held. Wakes up one uncancelable waiter and every cancelable one, they all
+// re-check the queue anyway
+func (s *signal) wakeOne() {
+	if s.waiters == 0 {
+		return
+	}
+	s.cond.Signal()
+	s.wakeChannel()
+}
+
+// Has to be called with the queue's lock held
+func (s *signal) wakeAll() {
+	if s.waiters == 0 {
+		return
+	}
+	s.cond.Broadcast()
+	s.wakeChannel()
+}
+
+func (s *signal) wakeChannel() {
+	if s.ch != nil {
+		close(s.ch)
+		s.ch = nil
+	}
+}
+
+// Blocks while the queue is full, or until done fires
+func (q *queue[T]) push(in T, done <-chan struct{}) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for !q.closed && q.size == len(q.items) {
+		if !q.notFull.park(done) {
+			return errQueueCanceled
+		}
+	}
+
+	if q.closed {
+		return ErrQueueClosed
+	}
+
+	q.items[(q.head+q.size)%len(q.items)] = in
+	q.size++
+	q.notEmpty.wakeOne()
+
+	return nil
+}
+
+// Non-blocking 'push'. Returns errQueueFull if there's no room right now
+func (q *queue[T]) tryPush(in T) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return ErrQueueClosed
+	}
+	if q.size == len(q.items) {
+		return errQueueFull
+	}
+
+	q.items[(q.head+q.size)%len(q.items)] = in
+	q.size++
+	q.notEmpty.wakeOne()
+
+	return nil
+}
+
+// Blocks while the queue is empty, or until done fires. Once the queue has been closed, the remaining items are
+// still handed out and ErrQueueClosed is only returned after it has been fully drained
+func (q *queue[T]) pop(done <-chan struct{}) (T, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for !q.closed && q.size == 0 {
+		if !q.notEmpty.park(done) {
+			return None[T](), errQueueCanceled
+		}
+	}
+
+	if q.size == 0 {
+		return None[T](), ErrQueueClosed
+	}
+
+	return q.take(), nil
+}
+
+// Non-blocking 'pop'. Returns errQueueEmpty if there's nothing to take right now
+func (q *queue[T]) tryPop() (T, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.size == 0 {
+		if q.closed {
+			return None[T](), ErrQueueClosed
+		}
+		return None[T](), errQueueEmpty
+	}
+
+	return q.take(), nil
+}
+
+// Has to be called with q.mu held and a non-empty queue
+func (q *queue[T]) take() T {
+	i := q.head
+	if q.lifo {
+		i = (q.head + q.size - 1) % len(q.items)
+	} else {
+		q.head = (q.head + 1) % len(q.items)
+	}
+
+	in := q.items[i]
+	q.items[i] = None[T]() // Don't keep a reference to the item around
+	q.size--
+	q.notFull.wakeOne()
+
+	return in
+}
+
+// Takes the oldest half of the queued items, rounded up, at once. Returns nil if the queue is empty
+func (q *queue[T]) takeHalf() []T {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	res := make([]T, (q.size+1)/2)
+	for i := range res {
+		res[i] = q.items[q.head]
+		q.items[q.head] = None[T]()
+		q.head = (q.head + 1) % len(q.items)
+	}
+	q.size -= len(res)
+
+	if len(res) == 0 {
+		return nil
+	}
+	q.notFull.wakeAll() // Made room for more than one
+	return res
+}
+
+// Removes the oldest item matching fn, keeping the order of everything else. Reports whether one was found
+func (q *queue[T]) remove(fn func(T) bool) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for i := 0; i < q.size; i++ {
+		if !fn(q.items[(q.head+i)%len(q.items)]) {
+			continue
+		}
+
+		for j := i; j < q.size-1; j++ {
+			q.items[(q.head+j)%len(q.items)] = q.items[(q.head+j+1)%len(q.items)]
+		}
+		q.items[(q.head+q.size-1)%len(q.items)] = None[T]()
+		q.size--
+		q.notFull.wakeOne()
+
+		return true
+	}
+
+	return false
+}
+
+func (q *queue[T]) len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.size
+}
+
+// Fixed at construction, so no lock needed
+func (q *queue[T]) cap() int {
+	return len(q.items)
+}
+
+func (q *queue[T]) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return
+	}
+
+	q.closed = true
+	q.notEmpty.wakeAll()
+	q.notFull.wakeAll()
+}
+
+// Closes the queue and takes every queued item, oldest first, under the same lock hold, so no pop can sneak in
+// between the two
+func (q *queue[T]) closeAndDrain() []T {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	res := make([]T, q.size)
+	for i := range res {
+		j := (q.head + i) % len(q.items)
+		res[i] = q.items[j]
+		q.items[j] = None[T]()
+	}
+	q.head, q.size = 0, 0
+
+	q.closed = true
+	q.notEmpty.wakeAll()
+	q.notFull.wakeAll()
+
+	return res
+}
+
+func (q *queue[T]) isClosed() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.closed
+}
+
+// Copy of all queued items, oldest first
+func (q *queue[T]) snapshot() []T {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	res := make([]T, q.size)
+	for i := 0; i < q.size; i++ {
+		res[i] = q.items[(q.head+i)%len(q.items)]
+	}
+
+	return res
+}
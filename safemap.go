@@ -0,0 +1,92 @@
+package btils
+
+import "sync"
+
+// Goroutine-safe map. 'GetOrCompute' computes missing values lazily and exactly once per key, without holding up
+// operations on other keys while doing so
+type SafeMap[K comparable, V any] struct {
+	mu      sync.Mutex
+	values  map[K]V
+	pending map[K]chan struct{}
+}
+
+func NewSafeMap[K comparable, V any]() *SafeMap[K, V] {
+	return &SafeMap[K, V]{
+		values:  make(map[K]V),
+		pending: make(map[K]chan struct{}),
+	}
+}
+
+func (m *SafeMap[K, V]) Get(key K) (V, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	v, ok := m.values[key]
+	return v, ok
+}
+
+func (m *SafeMap[K, V]) Set(key K, value V) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.values[key] = value
+}
+
+func (m *SafeMap[K, V]) Delete(key K) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.values, key)
+}
+
+func (m *SafeMap[K, V]) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return len(m.values)
+}
+
+// Returns the value stored for key, computing and storing it with fn if it's missing. Concurrent calls for the same
+// key wait for the first one's fn instead of running their own. If fn panics, nothing is stored and the next
+// caller runs its fn instead
+func (m *SafeMap[K, V]) GetOrCompute(key K, fn func() V) V {
+	m.mu.Lock()
+	for {
+		if v, ok := m.values[key]; ok {
+			m.mu.Unlock()
+			return v
+		}
+
+		done, ok := m.pending[key]
+		if !ok {
+			break
+		}
+
+		m.mu.Unlock()
+		<-done
+		// The value might have been deleted again in the meantime, so check from the top
+		m.mu.Lock()
+	}
+
+	done := make(chan struct{})
+	m.pending[key] = done
+	m.mu.Unlock()
+
+	// Deferred, so a panicking fn doesn't leave everyone waiting for this key blocked forever. They start over
+	// and the next one runs fn itself
+	defer func() {
+		m.mu.Lock()
+		delete(m.pending, key)
+		m.mu.Unlock()
+
+		close(done)
+	}()
+
+	v := fn()
+
+	m.mu.Lock()
+	m.values[key] = v
+	m.mu.Unlock()
+
+	return v
+}
@@ -0,0 +1,71 @@
+package btils
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestSafeMap(t *testing.T) {
+	m := NewSafeMap[string, int]()
+	m.Set("Foo", 1)
+
+	if v, ok := m.Get("Foo"); !ok || v != 1 {
+		t.Fatalf("expected Foo=1, got %d %v", v, ok)
+	}
+	if m.Len() != 1 {
+		t.Fatalf("expected len 1, got %d", m.Len())
+	}
+
+	m.Delete("Foo")
+	if _, ok := m.Get("Foo"); ok || m.Len() != 0 {
+		t.Fatal("expected Foo to be deleted")
+	}
+}
+
+func TestSafeMapGetOrCompute(t *testing.T) {
+	m := NewSafeMap[string, int]()
+
+	var calls atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v := m.GetOrCompute("Foo", func() int {
+				calls.Add(1)
+				return 42
+			})
+			if v != 42 {
+				t.Errorf("expected 42, got %d", v)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if calls.Load() != 1 {
+		t.Fatalf("expected fn to run once, ran %d times", calls.Load())
+	}
+}
+
+func TestSafeMapGetOrComputePanic(t *testing.T) {
+	m := NewSafeMap[string, int]()
+
+	func() {
+		defer func() {
+			if r := recover(); r != "Foo" {
+				t.Fatalf("expected the panic to be re-raised, got %v", r)
+			}
+		}()
+		m.GetOrCompute("Foo", func() int { panic("Foo") })
+	}()
+
+	if _, ok := m.Get("Foo"); ok {
+		t.Fatal("expected nothing to be stored for a panicking fn")
+	}
+
+	// Would block forever if the panicking computation was still pending
+	if v := m.GetOrCompute("Foo", func() int { return 42 }); v != 42 {
+		t.Fatalf("expected 42, got %d", v)
+	}
+}
@@ -0,0 +1,125 @@
+package btils
+
+import (
+	"container/list"
+	"context"
+	"sync"
+)
+
+// Weighted semaphore limiting how many units can be held at once. Waiters are served in FIFO order, so a large
+// AcquireN can't be starved by a stream of small ones
+type Semaphore struct {
+	mu      sync.Mutex
+	size    int
+	cur     int
+	waiters list.List
+}
+
+type semaphoreWaiter struct {
+	n     int
+	ready chan struct{}
+}
+
+func NewSemaphore(size int) *Semaphore {
+	return &Semaphore{size: size}
+}
+
+func (s *Semaphore) Acquire(ctx context.Context) error {
+	return s.AcquireN(ctx, 1)
+}
+
+// Blocks until n units are available or ctx is done, in which case ctx.Err() is returned and nothing is acquired.
+// Acquiring more than the semaphore's size blocks until ctx is done
+func (s *Semaphore) AcquireN(ctx context.Context, n int) error {
+	s.mu.Lock()
+	if s.size-s.cur >= n && s.waiters.Len() == 0 {
+		s.cur += n
+		s.mu.Unlock()
+		return nil
+	}
+
+	if n > s.size {
+		s.mu.Unlock()
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	ready := make(chan struct{})
+	elem := s.waiters.PushBack(semaphoreWaiter{n: n, ready: ready})
+	s.mu.Unlock()
+
+	select {
+	case <-ready:
+		return nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		select {
+		case <-ready:
+			// Acquired right as ctx was done, give the units back so we don't leak them
+			s.cur -= n
+			s.notifyWaiters()
+		default:
+			front := s.waiters.Front() == elem
+			s.waiters.Remove(elem)
+			// If we were blocking the queue, the waiters behind us might fit now
+			if front && s.size > s.cur {
+				s.notifyWaiters()
+			}
+		}
+		s.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+func (s *Semaphore) TryAcquire() bool {
+	return s.TryAcquireN(1)
+}
+
+// Acquires n units without blocking, returning false if they aren't available right now
+func (s *Semaphore) TryAcquireN(n int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.size-s.cur >= n && s.waiters.Len() == 0 {
+		s.cur += n
+		return true
+	}
+	return false
+}
+
+func (s *Semaphore) Release() {
+	s.ReleaseN(1)
+}
+
+// Releases n units. Panics when releasing more than is currently held, since that always indicates a bug
+func (s *Semaphore) ReleaseN(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if n > s.cur {
+		panic("btils: semaphore released more than held")
+	}
+
+	s.cur -= n
+	s.notifyWaiters()
+}
+
+// Has to be called with s.mu held
+func (s *Semaphore) notifyWaiters() {
+	for {
+		front := s.waiters.Front()
+		if front == nil {
+			return
+		}
+
+		w := front.Value.(semaphoreWaiter)
+		if s.size-s.cur < w.n {
+			// Strict FIFO, don't let smaller waiters overtake the front one
+			return
+		}
+
+		s.cur += w.n
+		s.waiters.Remove(front)
+		close(w.ready)
+	}
+}
@@ -0,0 +1,72 @@
+package btils
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSemaphoreBlocking(t *testing.T) {
+	s := NewSemaphore(2)
+	ctx := context.Background()
+
+	if err := s.AcquireN(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+	if s.TryAcquire() {
+		t.Fatal("expected TryAcquire to fail on a full semaphore")
+	}
+
+	acquired := make(chan struct{})
+	go func() {
+		s.Acquire(ctx)
+		close(acquired)
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("expected Acquire to block on a full semaphore")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	s.Release()
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("expected Acquire to unblock after Release")
+	}
+
+	s.ReleaseN(2)
+	if !s.TryAcquireN(2) {
+		t.Fatal("expected TryAcquireN to succeed on an empty semaphore")
+	}
+}
+
+func TestSemaphoreCancel(t *testing.T) {
+	s := NewSemaphore(1)
+	s.Acquire(context.Background())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if err := s.Acquire(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("expected DeadlineExceeded, got %v", err)
+	}
+
+	// The cancelled waiter must not hold on to anything
+	s.Release()
+	if !s.TryAcquire() {
+		t.Fatal("expected the semaphore to be free after cancellation")
+	}
+}
+
+func TestSemaphoreOverRelease(t *testing.T) {
+	s := NewSemaphore(1)
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected over-release to panic")
+		}
+	}()
+	s.Release()
+}
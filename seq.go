@@ -0,0 +1,45 @@
+package btils
+
+import "iter"
+
+// Yields the elements of s in order
+func SeqFromSlice[T any](s []T) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for _, v := range s {
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// Collects everything seq yields into a slice. This is where a lazy pipeline finally allocates
+func SliceFromSeq[T any](seq iter.Seq[T]) []T {
+	res := []T{}
+	for v := range seq {
+		res = append(res, v)
+	}
+	return res
+}
+
+// Lazily converts every element of seq, fn only runs once the consumer asks for the next element
+func MapSeq[T, U any](seq iter.Seq[T], fn func(T) U) iter.Seq[U] {
+	return func(yield func(U) bool) {
+		for v := range seq {
+			if !yield(fn(v)) {
+				return
+			}
+		}
+	}
+}
+
+// Lazily yields only the elements of seq matching pred
+func FilterSeq[T any](seq iter.Seq[T], pred func(T) bool) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for v := range seq {
+			if pred(v) && !yield(v) {
+				return
+			}
+		}
+	}
+}
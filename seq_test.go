@@ -0,0 +1,34 @@
+package btils
+
+import "testing"
+
+func TestSeq(t *testing.T) {
+	mapped := 0
+	seq := FilterSeq(MapSeq(SeqFromSlice([]int{1, 2, 3, 4, 5, 6}), func(v int) int {
+		mapped++
+		return v * 10
+	}), func(v int) bool {
+		return v%20 == 0
+	})
+
+	if mapped != 0 {
+		t.Fatal("expected nothing to be evaluated before iterating")
+	}
+
+	for v := range seq {
+		if v != 20 {
+			t.Fatalf("expected 20 first, got %d", v)
+		}
+		break
+	}
+	if mapped != 2 {
+		t.Fatalf("expected breaking early to stop after 2 elements, mapped %d", mapped)
+	}
+
+	if res := SliceFromSeq(seq); len(res) != 3 || res[2] != 60 {
+		t.Fatalf("unexpected result %v", res)
+	}
+	if res := SliceFromSeq(SeqFromSlice[int](nil)); res == nil || len(res) != 0 {
+		t.Fatalf("expected an empty slice, got %#v", res)
+	}
+}
@@ -0,0 +1,182 @@
+package btils
+
+import "sync/atomic"
+
+// Queue capacity every worker of a 'ShardedThreadManager' gets unless 'WithShardedQueueSize' is used
+const defaultShardQueueSize = 16
+
+type shardedOptions struct {
+	queueSize    int
+	workStealing bool
+}
+
+// Options of 'NewShardedThreadManager'. Separate from 'Option', since most of those don't apply to a sharded pool
+type ShardedOption func(*shardedOptions)
+
+// Number of items that can be queued across all workers, split evenly between them, so Feed blocks once the
+// worker responsible for a key has n/workers items queued. Defaults to 16 per worker
+func WithShardedQueueSize(n int) ShardedOption {
+	return func(o *shardedOptions) {
+		o.queueSize = n
+	}
+}
+
+// Idle workers take the oldest half of the busiest worker's queue, which keeps skewed key distributions from
+// leaving workers idle. This relaxes the per-key ordering guarantee: a stolen item may be processed concurrently
+// with, or before, an earlier item of the same key
+func WithWorkStealing() ShardedOption {
+	return func(o *shardedOptions) {
+		o.workStealing = true
+	}
+}
+
+// Like 'ThreaderManager', but every item is routed to a fixed worker based on its key. Items with the same key are
+// therefore processed sequentially and in the order they were fed, while different keys are still processed in
+// parallel. Overall throughput depends on the key distribution: if most items share a key, most work ends up on a
+// single worker, unless 'WithWorkStealing' is used
+type ShardedThreadManager[T any] struct {
+	queues []*queue[T]
+
+	keyFn    func(in T) uint64
+	callback func(in T)
+	options  shardedOptions
+
+	counter int64
+
+	// Buffered wake-up tokens for idle workers looking for something to steal
+	wake chan struct{}
+}
+
+func NewShardedThreadManager[T any](workers int, keyFn func(in T) uint64, callback func(in T), opts ...ShardedOption) *ShardedThreadManager[T] {
+	workers = max(workers, 1)
+
+	tm := &ShardedThreadManager[T]{
+		queues: make([]*queue[T], workers),
+
+		keyFn:    keyFn,
+		callback: callback,
+
+		wake: make(chan struct{}, workers),
+	}
+
+	for _, opt := range opts {
+		opt(&tm.options)
+	}
+
+	capacity := defaultShardQueueSize
+	if tm.options.queueSize > 0 {
+		capacity = max(tm.options.queueSize/workers, 1)
+	}
+	for i := range tm.queues {
+		tm.queues[i] = newQueue[T](capacity)
+	}
+
+	return tm
+}
+
+func (tm *ShardedThreadManager[T]) Start() {
+	for i := range tm.queues {
+		if tm.options.workStealing {
+			go tm.workStealing(i)
+		} else {
+			go tm.work(i)
+		}
+	}
+}
+
+func (tm *ShardedThreadManager[T]) work(i int) {
+	for {
+		in, err := tm.queues[i].pop(nil)
+		if err != nil {
+			return
+		}
+
+		tm.process(in)
+	}
+}
+
+func (tm *ShardedThreadManager[T]) workStealing(i int) {
+	own := tm.queues[i]
+	for {
+		in, err := own.tryPop()
+		if err == nil {
+			tm.process(in)
+			continue
+		}
+		if err == ErrQueueClosed {
+			return
+		}
+
+		if stolen := tm.steal(i); len(stolen) > 0 {
+			for _, in := range stolen {
+				tm.process(in)
+			}
+			continue
+		}
+
+		// Checked under the queue's lock, so nothing that's fed in between can be missed. Gives up once another
+		// worker was fed something, so we can try stealing it
+		in, err = own.pop(tm.wake)
+		if err == nil {
+			tm.process(in)
+		} else if err == ErrQueueClosed {
+			return
+		}
+	}
+}
+
+// Takes the oldest half of the fullest queue other than our own
+func (tm *ShardedThreadManager[T]) steal(self int) []T {
+	victim, most := -1, 0
+	for i, q := range tm.queues {
+		if i == self {
+			continue
+		}
+		if n := q.len(); n > most {
+			victim, most = i, n
+		}
+	}
+
+	if victim < 0 {
+		return nil
+	}
+
+	return tm.queues[victim].takeHalf()
+}
+
+func (tm *ShardedThreadManager[T]) process(in T) {
+	tm.callback(in)
+	atomic.AddInt64(&tm.counter, -1)
+}
+
+// Index of the worker responsible for key
+func (tm *ShardedThreadManager[T]) shard(key uint64) int {
+	// Mixed, so sequential keys don't all land on neighbouring workers in lockstep
+	return int(mix64(key) % uint64(len(tm.queues)))
+}
+
+// Blocks while the worker responsible for the item's key is busy and its queue is full
+func (tm *ShardedThreadManager[T]) Feed(in T) {
+	atomic.AddInt64(&tm.counter, 1)
+	if tm.queues[tm.shard(tm.keyFn(in))].push(in, nil) != nil {
+		atomic.AddInt64(&tm.counter, -1)
+		panic("btils: Feed called on a stopped ShardedThreadManager")
+	}
+
+	if tm.options.workStealing {
+		select {
+		case tm.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (tm *ShardedThreadManager[T]) IsDone() bool {
+	return atomic.LoadInt64(&tm.counter) == 0
+}
+
+func (tm *ShardedThreadManager[T]) Stop() {
+	for _, q := range tm.queues {
+		q.close()
+	}
+}
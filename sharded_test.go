@@ -0,0 +1,159 @@
+package btils
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type shardedItem struct {
+	key uint64
+	seq int
+}
+
+func TestShardedThreadManager(t *testing.T) {
+	var active [4]atomic.Int32
+	var overlap atomic.Bool
+
+	var mu sync.Mutex
+	last := map[uint64]int{}
+	outOfOrder := false
+
+	tm := NewShardedThreadManager[shardedItem](3, func(in shardedItem) uint64 {
+		return in.key
+	}, func(in shardedItem) {
+		if active[in.key].Add(1) > 1 {
+			overlap.Store(true)
+		}
+		time.Sleep(time.Millisecond)
+
+		mu.Lock()
+		if seq, ok := last[in.key]; ok && seq > in.seq {
+			outOfOrder = true
+		}
+		last[in.key] = in.seq
+		mu.Unlock()
+
+		active[in.key].Add(-1)
+	})
+
+	tm.Start()
+	defer tm.Stop()
+
+	for seq := 0; seq < 50; seq++ {
+		tm.Feed(shardedItem{key: uint64(seq % 4), seq: seq})
+	}
+	waitFor(t, tm.IsDone)
+
+	if overlap.Load() {
+		t.Fatal("items with the same key were processed concurrently")
+	}
+	if outOfOrder {
+		t.Fatal("items with the same key were processed out of order")
+	}
+}
+
+func TestShardedWorkStealing(t *testing.T) {
+	var active, peak atomic.Int32
+	tm := NewShardedThreadManager[int](4, func(in int) uint64 {
+		return 0 // Every item lands on the same worker
+	}, func(in int) {
+		n := active.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(2 * time.Millisecond)
+		active.Add(-1)
+	}, WithWorkStealing())
+
+	tm.Start()
+	defer tm.Stop()
+
+	for i := 0; i < 50; i++ {
+		tm.Feed(i)
+	}
+	waitFor(t, tm.IsDone)
+
+	if peak.Load() < 2 {
+		t.Fatal("expected idle workers to steal from the busy one")
+	}
+}
+
+func TestShardedQueueSize(t *testing.T) {
+	tm := NewShardedThreadManager[int](4, func(in int) uint64 { return 0 }, func(in int) {}, WithShardedQueueSize(8))
+	defer tm.Stop()
+
+	// Not started, so the hot shard only takes its share of 8/4 items before Feed blocks
+	fed := make(chan int, 3)
+	go func() {
+		for i := 0; i < 3; i++ {
+			tm.Feed(i)
+			fed <- i
+		}
+	}()
+
+	waitFor(t, func() bool { return len(fed) == 2 })
+	time.Sleep(10 * time.Millisecond)
+	if len(fed) != 2 {
+		t.Fatalf("expected Feed to block once the shard is full, fed %d", len(fed))
+	}
+
+	tm.Start()
+	waitFor(t, func() bool { return len(fed) == 3 })
+	waitFor(t, tm.IsDone)
+}
+
+func TestShardedStealHalf(t *testing.T) {
+	q := newQueue[int](8)
+	for i := 0; i < 5; i++ {
+		q.push(i, nil)
+	}
+
+	if stolen := q.takeHalf(); !SliceEqual(stolen, []int{0, 1, 2}) {
+		t.Fatalf("expected the oldest half rounded up, got %v", stolen)
+	}
+	if rest := q.snapshot(); !SliceEqual(rest, []int{3, 4}) {
+		t.Fatalf("expected the newer half to stay queued, got %v", rest)
+	}
+
+	q.takeHalf()
+	q.takeHalf()
+	if stolen := q.takeHalf(); stolen != nil {
+		t.Fatalf("expected nil for an empty queue, got %v", stolen)
+	}
+}
+
+func benchmarkShardedSkewed(b *testing.B, opts ...ShardedOption) {
+	tm := NewShardedThreadManager[int](8, func(in int) uint64 {
+		// 90% of all items share a single key
+		if in%10 != 0 {
+			return 0
+		}
+		return uint64(in)
+	}, func(in int) {
+		time.Sleep(10 * time.Microsecond)
+	}, opts...)
+
+	tm.Start()
+	defer tm.Stop()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tm.Feed(i)
+	}
+	for !tm.IsDone() {
+		time.Sleep(time.Microsecond)
+	}
+}
+
+func BenchmarkShardedSkewed(b *testing.B) {
+	benchmarkShardedSkewed(b)
+}
+
+func BenchmarkShardedSkewedWorkStealing(b *testing.B) {
+	benchmarkShardedSkewed(b, WithWorkStealing())
+}
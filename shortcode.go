@@ -0,0 +1,50 @@
+package btils
+
+// The 32 symbols that are hard to mix up when read or typed: no 0/O, no 1/l/I and no lower case at all
+const unambiguousChars = "23456789ABCDEFGHJKLMNPQRSTUVWXYZ"
+
+type shortCodeOptions struct {
+	unambiguous bool
+}
+
+type ShortCodeOption func(*shortCodeOptions)
+
+// Only use upper case letters and digits that can't be confused with each other (no 0/O, 1/l/I). Leaves 32
+// symbols instead of 64, i.e. 5 instead of 6 bits per character
+func WithUnambiguous() ShortCodeOption {
+	return func(o *shortCodeOptions) {
+		o.unambiguous = true
+	}
+}
+
+// Generates a random code of the given length for human-facing purposes like coupon codes or share links, using the
+// same entropy source and bit-mapping as 'NewUID'. This is NOT a UID: every character less divides the number of
+// possible values by 64 (32 with 'WithUnambiguous'), so collisions get likely quickly, e.g. an 8 character
+// unambiguous code reaches a 50% collision probability after roughly 1.2 million codes. Check for collisions
+// wherever uniqueness matters, and never use these as secrets
+func NewShortCode(length int, opts ...ShortCodeOption) string {
+	var o shortCodeOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	alphabet, bits := randChars, 6
+	if o.unambiguous {
+		alphabet, bits = unambiguousChars, 5
+	}
+	mask := uint32(1)<<bits - 1
+	perRand := 32 / bits
+
+	source := entropy()
+	b := make([]byte, max(length, 0))
+	for i := 0; i < len(b); {
+		rnd := source()
+		for j := 0; j < perRand && i < len(b); j++ {
+			b[i] = alphabet[rnd&mask]
+			rnd >>= bits
+			i++
+		}
+	}
+
+	return string(b)
+}
@@ -0,0 +1,36 @@
+package btils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewShortCode(t *testing.T) {
+	for _, length := range []int{0, 1, 6, 7, 13} {
+		code := NewShortCode(length)
+		if len(code) != length {
+			t.Fatalf("expected a code of length %d, got %q", length, code)
+		}
+		for i := 0; i < len(code); i++ {
+			if strings.IndexByte(randChars, code[i]) < 0 {
+				t.Fatalf("unexpected character in %q", code)
+			}
+		}
+	}
+
+	seen := map[byte]bool{}
+	for i := 0; i < 10000; i++ {
+		code := NewShortCode(8, WithUnambiguous())
+		if strings.ContainsAny(code, "0O1lIabcdefghijklmnopqrstuvwxyz_-") {
+			t.Fatalf("unambiguous code %q contains an excluded character", code)
+		}
+		for j := 0; j < len(code); j++ {
+			seen[code[j]] = true
+		}
+	}
+
+	// Every symbol of the alphabet should show up eventually
+	if len(seen) != len(unambiguousChars) {
+		t.Fatalf("expected all %d symbols to be used, got %d", len(unambiguousChars), len(seen))
+	}
+}
@@ -0,0 +1,302 @@
+package btils
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+type Pair[A, B any] struct {
+	First  A
+	Second B
+}
+
+// Pairs up the elements of as and bs by index. If the lengths differ, the result is truncated to the shorter one
+// and the remaining elements of the longer slice are ignored
+func Zip[A, B any](as []A, bs []B) []Pair[A, B] {
+	n := min(len(as), len(bs))
+
+	res := make([]Pair[A, B], n)
+	for i := 0; i < n; i++ {
+		res[i] = Pair[A, B]{First: as[i], Second: bs[i]}
+	}
+
+	return res
+}
+
+// Reverses 'Zip', splitting the pairs back into two slices of equal length
+func Unzip[A, B any](pairs []Pair[A, B]) ([]A, []B) {
+	as := make([]A, len(pairs))
+	bs := make([]B, len(pairs))
+	for i, p := range pairs {
+		as[i] = p.First
+		bs[i] = p.Second
+	}
+
+	return as, bs
+}
+
+// Reverses s in place
+func Reverse[T any](s []T) {
+	for i, j := 0, len(s)-1; i < j; i, j = i+1, j-1 {
+		s[i], s[j] = s[j], s[i]
+	}
+}
+
+// Shuffles s in place. Pass a seeded r for reproducible results, or nil to use the math/rand package source
+func Shuffle[T any](s []T, r *rand.Rand) {
+	swap := func(i, j int) {
+		s[i], s[j] = s[j], s[i]
+	}
+
+	if r == nil {
+		rand.Shuffle(len(s), swap)
+		return
+	}
+	r.Shuffle(len(s), swap)
+}
+
+// Filters and converts s in a single pass. fn returns the converted value and whether to keep it.
+// Returns an empty, non-nil slice if nothing is kept
+func FilterMap[T, U any](s []T, fn func(T) (U, bool)) []U {
+	// We can't know how much survives, so don't reserve the full length up front
+	res := make([]U, 0, len(s)/2)
+	for _, v := range s {
+		if u, ok := fn(v); ok {
+			res = append(res, u)
+		}
+	}
+	return res
+}
+
+// Number of occurrences of target in s
+func Count[T comparable](s []T, target T) int {
+	n := 0
+	for _, v := range s {
+		if v == target {
+			n++
+		}
+	}
+	return n
+}
+
+// Number of elements in s matching pred
+func CountFunc[T any](s []T, pred func(T) bool) int {
+	n := 0
+	for _, v := range s {
+		if pred(v) {
+			n++
+		}
+	}
+	return n
+}
+
+// Returns all len(s)-size+1 contiguous, overlapping windows of s. Unlike chunking, consecutive windows share all
+// but one element. The windows are sub-slices of s, so they aren't copies. Returns an empty result if size is larger
+// than s and panics if size isn't positive
+func SlidingWindow[T any](s []T, size int) [][]T {
+	if size <= 0 {
+		panic("btils: SlidingWindow size must be positive")
+	}
+	if size > len(s) {
+		return [][]T{}
+	}
+
+	res := make([][]T, len(s)-size+1)
+	for i := range res {
+		// Cap the window so appending to it can't overwrite the next elements of s
+		res[i] = s[i : i+size : i+size]
+	}
+	return res
+}
+
+// Returns the first element matching pred. The bool distinguishes finding a zero value from finding nothing
+func Find[T any](s []T, pred func(T) bool) (T, bool) {
+	if i := FindIndex(s, pred); i >= 0 {
+		return s[i], true
+	}
+	return None[T](), false
+}
+
+// Index of the first element matching pred, or -1
+func FindIndex[T any](s []T, pred func(T) bool) int {
+	for i, v := range s {
+		if pred(v) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Slice of n copies of v. Returns an empty slice for a negative n
+func Repeat[T any](v T, n int) []T {
+	res := make([]T, max(n, 0))
+	for i := range res {
+		res[i] = v
+	}
+	return res
+}
+
+// Builds a slice of length n by calling fn for every index. Returns an empty slice for a negative n
+func Times[T any](n int, fn func(i int) T) []T {
+	res := make([]T, max(n, 0))
+	for i := range res {
+		res[i] = fn(i)
+	}
+	return res
+}
+
+// Maps every element to a slice and concatenates the results. Elements mapping to nil or empty slices contribute
+// nothing. Returns an empty, non-nil slice if nothing is produced
+func FlatMap[T, U any](s []T, fn func(T) []U) []U {
+	// Keep the expansions around so the result can be allocated at its exact size
+	parts := make([][]U, len(s))
+	total := 0
+	for i, v := range s {
+		parts[i] = fn(v)
+		total += len(parts[i])
+	}
+
+	res := make([]U, 0, total)
+	for _, part := range parts {
+		res = append(res, part...)
+	}
+	return res
+}
+
+// Reports whether any element matches pred, stopping at the first match
+func Any[T any](s []T, pred func(T) bool) bool {
+	return FindIndex(s, pred) >= 0
+}
+
+// Reports whether every element matches pred, stopping at the first mismatch. True for an empty slice
+func All[T any](s []T, pred func(T) bool) bool {
+	for _, v := range s {
+		if !pred(v) {
+			return false
+		}
+	}
+	return true
+}
+
+// Reports whether a and b have the same length and equal elements in the same order. Like slices.Equal, a nil and
+// an empty slice are equal, unlike with reflect.DeepEqual
+func SliceEqual[T comparable](a, b []T) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Like 'SliceEqual', but compares elements with eq, e.g. for non-comparable or approximately equal elements
+func SliceEqualFunc[A, B any](a []A, b []B, eq func(A, B) bool) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !eq(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Converts every element with fn and joins the results with sep, e.g. JoinFunc(ids, ",", strconv.Itoa)
+func JoinFunc[T any](s []T, sep string, fn func(T) string) string {
+	var sb strings.Builder
+	for i, v := range s {
+		if i > 0 {
+			sb.WriteString(sep)
+		}
+		sb.WriteString(fn(v))
+	}
+	return sb.String()
+}
+
+// Splits s around sep and parses every piece with fn. An empty s results in an empty slice instead of a single
+// empty piece. Every other piece is parsed as-is, so a trailing separator passes an empty last piece to fn.
+// Errors for all failing pieces are joined, each one naming its index, and no slice is returned in that case
+func SplitMap[T any](s, sep string, fn func(string) (T, error)) ([]T, error) {
+	if s == "" {
+		return []T{}, nil
+	}
+
+	pieces := strings.Split(s, sep)
+	res := make([]T, len(pieces))
+
+	var errs []error
+	for i, piece := range pieces {
+		v, err := fn(piece)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("btils: piece %d (%q): %w", i, piece, err))
+			continue
+		}
+		res[i] = v
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return res, nil
+}
+
+// Compares old and new as multisets, ignoring order. added holds the elements of new that old lacks, removed the
+// elements of old that new lacks, both in the order they appear. Duplicates are counted, so going from [a a b] to
+// [a c] adds [c] and removes [a b]. Both results are empty, not nil, if nothing changed
+func Diff[T comparable](old, new []T) (added, removed []T) {
+	counts := make(map[T]int, len(old))
+	for _, v := range old {
+		counts[v]++
+	}
+
+	added = []T{}
+	for _, v := range new {
+		if counts[v] > 0 {
+			counts[v]--
+			continue
+		}
+		added = append(added, v)
+	}
+
+	// Whatever is left over in counts wasn't matched by new
+	removed = []T{}
+	for _, v := range old {
+		if counts[v] > 0 {
+			counts[v]--
+			removed = append(removed, v)
+		}
+	}
+
+	return added, removed
+}
+
+// Like a fold, but keeps every intermediate accumulator: res[i] is the accumulator after folding s[i], starting
+// from init. A running sum is Scan(s, 0, func(acc, v int) int { return acc + v }). Empty input gives an empty slice
+func Scan[T, U any](s []T, init U, fn func(U, T) U) []U {
+	res := make([]U, len(s))
+	acc := init
+	for i, v := range s {
+		acc = fn(acc, v)
+		res[i] = acc
+	}
+	return res
+}
+
+// Folds s into init, but fn also reports whether to keep going. The accumulator fn returns alongside false is
+// still the result, the remaining elements are never visited
+func ReduceWhile[T, U any](s []T, init U, fn func(U, T) (U, bool)) U {
+	acc := init
+	for _, v := range s {
+		var ok bool
+		if acc, ok = fn(acc, v); !ok {
+			break
+		}
+	}
+	return acc
+}
@@ -0,0 +1,347 @@
+package btils
+
+import (
+	"math/rand"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestZipUnzip(t *testing.T) {
+	pairs := Zip([]int{1, 2, 3}, []string{"Foo", "Baar"})
+	if len(pairs) != 2 {
+		t.Fatalf("expected zip to truncate to 2 pairs, got %d", len(pairs))
+	}
+	if pairs[1].First != 2 || pairs[1].Second != "Baar" {
+		t.Fatalf("unexpected pair %+v", pairs[1])
+	}
+
+	as, bs := Unzip(pairs)
+	if len(as) != 2 || len(bs) != 2 || as[0] != 1 || bs[0] != "Foo" {
+		t.Fatalf("unexpected unzip result %v %v", as, bs)
+	}
+
+	if len(Zip[int, int](nil, []int{1})) != 0 {
+		t.Fatal("expected zipping with a nil slice to be empty")
+	}
+}
+
+func TestReverse(t *testing.T) {
+	s := []int{1, 2, 3, 4}
+	Reverse(s)
+	if s[0] != 4 || s[1] != 3 || s[2] != 2 || s[3] != 1 {
+		t.Fatalf("unexpected reverse result %v", s)
+	}
+
+	// Must not panic
+	Reverse([]int{})
+	Reverse([]int{1})
+}
+
+func TestShuffle(t *testing.T) {
+	a := []int{1, 2, 3, 4, 5, 6, 7, 8}
+	b := []int{1, 2, 3, 4, 5, 6, 7, 8}
+	Shuffle(a, rand.New(rand.NewSource(42)))
+	Shuffle(b, rand.New(rand.NewSource(42)))
+
+	sum := 0
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("expected the same seed to shuffle identically, got %v and %v", a, b)
+		}
+		sum += a[i]
+	}
+	if sum != 36 {
+		t.Fatalf("shuffle lost elements: %v", a)
+	}
+
+	Shuffle(a, nil)
+	Shuffle([]int{}, nil)
+	Shuffle([]int{1}, nil)
+}
+
+func TestFilterMap(t *testing.T) {
+	double := func(keep func(int) bool) func(int) (int, bool) {
+		return func(v int) (int, bool) {
+			return v * 2, keep(v)
+		}
+	}
+	s := []int{1, 2, 3, 4}
+
+	if res := FilterMap(s, double(func(int) bool { return true })); len(res) != 4 || res[3] != 8 {
+		t.Fatalf("unexpected all-kept result %v", res)
+	}
+
+	if res := FilterMap(s, double(func(int) bool { return false })); res == nil || len(res) != 0 {
+		t.Fatalf("expected an empty non-nil slice, got %#v", res)
+	}
+
+	if res := FilterMap(s, double(func(v int) bool { return v%2 == 0 })); len(res) != 2 || res[0] != 4 || res[1] != 8 {
+		t.Fatalf("unexpected mixed result %v", res)
+	}
+}
+
+func TestCount(t *testing.T) {
+	s := []string{"Foo", "Baar", "Foo", "Baloo"}
+
+	if n := Count(s, "Foo"); n != 2 {
+		t.Fatalf("expected 2, got %d", n)
+	}
+	if n := Count(s, "Golang"); n != 0 {
+		t.Fatalf("expected 0, got %d", n)
+	}
+	if n := CountFunc(s, func(v string) bool { return len(v) > 3 }); n != 2 {
+		t.Fatalf("expected 2, got %d", n)
+	}
+
+	if Count(nil, "Foo") != 0 || CountFunc(nil, func(string) bool { return true }) != 0 {
+		t.Fatal("expected nil slices to count zero")
+	}
+}
+
+func TestSlidingWindow(t *testing.T) {
+	windows := SlidingWindow([]int{1, 2, 3, 4}, 2)
+	if len(windows) != 3 {
+		t.Fatalf("expected 3 windows, got %v", windows)
+	}
+	for i, w := range windows {
+		if len(w) != 2 || w[0] != i+1 || w[1] != i+2 {
+			t.Fatalf("unexpected window %d: %v", i, w)
+		}
+	}
+
+	if windows := SlidingWindow([]int{1, 2, 3}, 3); len(windows) != 1 || len(windows[0]) != 3 {
+		t.Fatalf("expected a single full window, got %v", windows)
+	}
+	if windows := SlidingWindow([]int{1, 2}, 3); windows == nil || len(windows) != 0 {
+		t.Fatalf("expected an empty result for an oversized window, got %v", windows)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a non-positive size to panic")
+		}
+	}()
+	SlidingWindow([]int{1}, 0)
+}
+
+func TestFind(t *testing.T) {
+	s := []int{3, 0, 5}
+
+	if v, ok := Find(s, func(v int) bool { return v > 3 }); !ok || v != 5 {
+		t.Fatalf("expected to find 5, got %d %v", v, ok)
+	}
+	if v, ok := Find(s, func(v int) bool { return v == 0 }); !ok || v != 0 {
+		t.Fatalf("expected to find the zero value, got %d %v", v, ok)
+	}
+	if _, ok := Find(s, func(v int) bool { return v > 10 }); ok {
+		t.Fatal("expected nothing to be found")
+	}
+
+	if i := FindIndex(s, func(v int) bool { return v == 0 }); i != 1 {
+		t.Fatalf("expected index 1, got %d", i)
+	}
+	if i := FindIndex(nil, func(v int) bool { return true }); i != -1 {
+		t.Fatalf("expected -1, got %d", i)
+	}
+}
+
+func TestRepeatTimes(t *testing.T) {
+	if s := Repeat("Foo", 3); len(s) != 3 || s[2] != "Foo" {
+		t.Fatalf("unexpected repeat result %v", s)
+	}
+	if s := Times(4, func(i int) int { return i * i }); len(s) != 4 || s[3] != 9 {
+		t.Fatalf("unexpected times result %v", s)
+	}
+
+	for _, n := range []int{0, -1} {
+		if s := Repeat("Foo", n); s == nil || len(s) != 0 {
+			t.Fatalf("expected an empty slice for n=%d, got %#v", n, s)
+		}
+		if s := Times(n, func(i int) int { return i }); s == nil || len(s) != 0 {
+			t.Fatalf("expected an empty slice for n=%d, got %#v", n, s)
+		}
+	}
+}
+
+func TestFlatMap(t *testing.T) {
+	res := FlatMap([]int{0, 1, 2, 3}, func(v int) []int {
+		return Repeat(v, v)
+	})
+	if len(res) != 6 || res[0] != 1 || res[5] != 3 {
+		t.Fatalf("unexpected result %v", res)
+	}
+
+	if res := FlatMap([]int{1, 2}, func(int) []string { return nil }); res == nil || len(res) != 0 {
+		t.Fatalf("expected an empty slice for empty expansions, got %#v", res)
+	}
+	if res := FlatMap(nil, func(v int) []int { return []int{v} }); res == nil || len(res) != 0 {
+		t.Fatalf("expected an empty slice for empty input, got %#v", res)
+	}
+}
+
+func TestAnyAll(t *testing.T) {
+	s := []int{1, 2, 3, 4}
+
+	inspected := 0
+	isEven := func(v int) bool {
+		inspected++
+		return v%2 == 0
+	}
+
+	if !Any(s, isEven) || inspected != 2 {
+		t.Fatalf("expected Any to stop after 2 elements, inspected %d", inspected)
+	}
+
+	inspected = 0
+	if All(s, isEven) || inspected != 1 {
+		t.Fatalf("expected All to stop after 1 element, inspected %d", inspected)
+	}
+
+	if Any(nil, isEven) || !All(nil, isEven) {
+		t.Fatal("expected Any to be false and All to be true for an empty slice")
+	}
+}
+
+func TestSliceEqual(t *testing.T) {
+	if !SliceEqual([]int{1, 2, 3}, []int{1, 2, 3}) {
+		t.Fatal("expected equal slices to be equal")
+	}
+	if SliceEqual([]int{1, 2, 3}, []int{1, 3, 2}) || SliceEqual([]int{1, 2}, []int{1, 2, 3}) {
+		t.Fatal("expected different slices to not be equal")
+	}
+	if !SliceEqual(nil, []int{}) {
+		t.Fatal("expected nil and empty slices to be equal")
+	}
+	if SliceEqual(nil, []int{0}) {
+		t.Fatal("expected nil to not equal a slice holding a zero value")
+	}
+
+	sameLength := func(a string, b []byte) bool { return len(a) == len(b) }
+	if !SliceEqualFunc([]string{"Foo", "Bo"}, [][]byte{[]byte("Baa"), []byte("Ba")}, sameLength) {
+		t.Fatal("expected SliceEqualFunc to use the predicate")
+	}
+	if SliceEqualFunc([]string{"Foo"}, nil, sameLength) {
+		t.Fatal("expected slices of different lengths to not be equal")
+	}
+}
+
+func BenchmarkSliceEqual(b *testing.B) {
+	x, y := Times(1024, func(i int) int { return i }), Times(1024, func(i int) int { return i })
+
+	b.Run("SliceEqual", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			SliceEqual(x, y)
+		}
+	})
+	b.Run("DeepEqual", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			reflect.DeepEqual(x, y)
+		}
+	})
+}
+
+func TestJoinFuncSplitMap(t *testing.T) {
+	if joined := JoinFunc([]int{1, 2, 3}, ", ", strconv.Itoa); joined != "1, 2, 3" {
+		t.Fatalf("unexpected join %q", joined)
+	}
+	if joined := JoinFunc(nil, ",", strconv.Itoa); joined != "" {
+		t.Fatalf("expected an empty string for an empty slice, got %q", joined)
+	}
+
+	ints, err := SplitMap("1,2,3", ",", strconv.Atoi)
+	if err != nil || !SliceEqual(ints, []int{1, 2, 3}) {
+		t.Fatalf("unexpected split %v, %v", ints, err)
+	}
+
+	ints, err = SplitMap("", ",", strconv.Atoi)
+	if err != nil || ints == nil || len(ints) != 0 {
+		t.Fatalf("expected an empty slice for an empty string, got %#v, %v", ints, err)
+	}
+
+	// The trailing separator produces an empty piece, which Atoi rejects
+	_, err = SplitMap("1,2,", ",", strconv.Atoi)
+	if err == nil || !strings.Contains(err.Error(), "piece 2") {
+		t.Fatalf("expected an error for the empty trailing piece, got %v", err)
+	}
+
+	_, err = SplitMap("x,2,y", ",", strconv.Atoi)
+	if err == nil || !strings.Contains(err.Error(), "piece 0") || !strings.Contains(err.Error(), "piece 2") {
+		t.Fatalf("expected the errors of every failing piece, got %v", err)
+	}
+
+	strs, err := SplitMap("Foo,,Baar", ",", func(s string) (string, error) { return s, nil })
+	if err != nil || !SliceEqual(strs, []string{"Foo", "", "Baar"}) {
+		t.Fatalf("expected empty pieces in the middle to be kept, got %q, %v", strs, err)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	for _, tc := range []struct {
+		old, new, added, removed []string
+	}{
+		{[]string{"a", "b"}, []string{"b", "c"}, []string{"c"}, []string{"a"}},
+		{[]string{"a", "a", "b"}, []string{"a", "c"}, []string{"c"}, []string{"a", "b"}},
+		{[]string{"a"}, []string{"a", "a"}, []string{"a"}, []string{}},
+		{[]string{"b", "a"}, []string{"a", "b"}, []string{}, []string{}},
+		{nil, []string{"a"}, []string{"a"}, []string{}},
+		{[]string{"a"}, nil, []string{}, []string{"a"}},
+		{nil, nil, []string{}, []string{}},
+	} {
+		added, removed := Diff(tc.old, tc.new)
+		if !SliceEqual(added, tc.added) || !SliceEqual(removed, tc.removed) {
+			t.Fatalf("Diff(%v, %v): expected +%v -%v, got +%v -%v", tc.old, tc.new, tc.added, tc.removed, added, removed)
+		}
+		if added == nil || removed == nil {
+			t.Fatalf("Diff(%v, %v): expected empty slices instead of nil", tc.old, tc.new)
+		}
+	}
+}
+
+func TestScan(t *testing.T) {
+	s := Times(100, func(i int) int { return i * 3 })
+	sums := Scan(s, 0, func(acc, v int) int { return acc + v })
+
+	running := 0
+	for i, v := range s {
+		running += v
+		if sums[i] != running {
+			t.Fatalf("expected %d at index %d, got %d", running, i, sums[i])
+		}
+	}
+
+	lengths := Scan([]string{"Foo", "Baar"}, "", func(acc, v string) string { return acc + v })
+	if !SliceEqual(lengths, []string{"Foo", "FooBaar"}) {
+		t.Fatalf("unexpected scan %v", lengths)
+	}
+
+	if res := Scan(nil, 0, func(acc, v int) int { return acc + v }); res == nil || len(res) != 0 {
+		t.Fatalf("expected an empty slice for empty input, got %#v", res)
+	}
+}
+
+func TestReduceWhile(t *testing.T) {
+	visited := 0
+	sum := ReduceWhile([]int{1, 2, 3, -1, 4, 5}, 0, func(acc, v int) (int, bool) {
+		visited++
+		if v < 0 {
+			return acc, false
+		}
+		return acc + v, true
+	})
+	if sum != 6 || visited != 4 {
+		t.Fatalf("expected to stop at the sentinel with 6, got %d after %d elements", sum, visited)
+	}
+
+	found := ReduceWhile([]string{"Foo", "Baar", "Baloo"}, "", func(_ string, v string) (string, bool) {
+		return v, v != "Baar"
+	})
+	if found != "Baar" {
+		t.Fatalf("expected the accumulator returned alongside false, got %q", found)
+	}
+
+	if res := ReduceWhile(nil, 5, func(acc, v int) (int, bool) { return acc + v, true }); res != 5 {
+		t.Fatalf("expected init for empty input, got %d", res)
+	}
+}
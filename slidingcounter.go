@@ -0,0 +1,92 @@
+package btils
+
+import (
+	"sync"
+	"time"
+)
+
+// Goroutine-safe event counter over a rolling time window. The window is split into fixed-width buckets, so
+// memory stays constant no matter how many events are recorded and events age out one bucket at a time
+type SlidingCounter struct {
+	mu     sync.Mutex
+	window time.Duration
+	width  time.Duration
+
+	counts []int64
+	// Which time slot (now / width) each bucket currently holds, buckets from older slots are stale
+	slots []int64
+
+	now func() time.Time
+}
+
+type SlidingCounterOption func(*SlidingCounter)
+
+// Replaces the clock events are bucketed by, e.g. with a fake one in tests. Defaults to time.Now
+func WithCounterClock(now func() time.Time) SlidingCounterOption {
+	return func(sc *SlidingCounter) {
+		sc.now = now
+	}
+}
+
+// Counts events over the last window, split into the given number of buckets. More buckets make events age out
+// more smoothly at the cost of a slower Count
+func NewSlidingCounter(window time.Duration, buckets int, opts ...SlidingCounterOption) *SlidingCounter {
+	buckets = max(buckets, 1)
+
+	sc := &SlidingCounter{
+		window: window,
+		width:  max(window/time.Duration(buckets), 1),
+		counts: make([]int64, buckets),
+		slots:  make([]int64, buckets),
+		now:    time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(sc)
+	}
+
+	return sc
+}
+
+// Has to be called with sc.mu held
+func (sc *SlidingCounter) slot() int64 {
+	return sc.now().UnixNano() / int64(sc.width)
+}
+
+func (sc *SlidingCounter) Inc() {
+	sc.Add(1)
+}
+
+func (sc *SlidingCounter) Add(n int64) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	slot := sc.slot()
+	i := int(slot % int64(len(sc.counts)))
+	if sc.slots[i] != slot {
+		sc.slots[i] = slot
+		sc.counts[i] = 0
+	}
+	sc.counts[i] += n
+}
+
+// Number of events recorded within the window
+func (sc *SlidingCounter) Count() int64 {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	oldest := sc.slot() - int64(len(sc.counts))
+
+	var res int64
+	for i, slot := range sc.slots {
+		if slot > oldest {
+			res += sc.counts[i]
+		}
+	}
+	return res
+}
+
+// Events per second, averaged over the whole window
+func (sc *SlidingCounter) Rate() float64 {
+	return float64(sc.Count()) / sc.window.Seconds()
+}
@@ -0,0 +1,60 @@
+package btils
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSlidingCounter(t *testing.T) {
+	now := time.Unix(1000, 0)
+	sc := NewSlidingCounter(10*time.Second, 10, WithCounterClock(func() time.Time { return now }))
+
+	sc.Add(5)
+	now = now.Add(4 * time.Second)
+	sc.Inc()
+
+	if sc.Count() != 6 {
+		t.Fatalf("expected 6 events within the window, got %d", sc.Count())
+	}
+	if sc.Rate() != 0.6 {
+		t.Fatalf("expected a rate of 0.6/s, got %f", sc.Rate())
+	}
+
+	// The first 5 events are 10s old now and have left the window
+	now = now.Add(6 * time.Second)
+	if sc.Count() != 1 {
+		t.Fatalf("expected the oldest events to age out, got %d", sc.Count())
+	}
+
+	// Reusing a bucket after a full lap must not keep its old count
+	sc.Add(2)
+	if sc.Count() != 3 {
+		t.Fatalf("expected 3 events, got %d", sc.Count())
+	}
+
+	now = now.Add(time.Hour)
+	if sc.Count() != 0 {
+		t.Fatalf("expected everything to age out, got %d", sc.Count())
+	}
+}
+
+func TestSlidingCounterConcurrent(t *testing.T) {
+	sc := NewSlidingCounter(time.Hour, 60)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				sc.Inc()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if sc.Count() != 8000 {
+		t.Fatalf("expected 8000 events, got %d", sc.Count())
+	}
+}
@@ -1,47 +1,896 @@
 package btils
 
-import "sync/atomic"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Size of the buffer behind 'Errors'. Once it's full, further errors are dropped instead of blocking workers
+const errorsBuffer = 64
+
+var (
+	ErrItemTimeout = errors.New("btils: item timed out")
+	ErrPoolStopped = errors.New("btils: ThreaderManager is stopped")
+)
+
+// Error reported on 'Errors' for a specific item
+type ItemError[T any] struct {
+	Item T
+	Err  error
+}
+
+func (e *ItemError[T]) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ItemError[T]) Unwrap() error {
+	return e.Err
+}
+
+// Error reported on 'Errors' for a batch of a 'NewBatchThreadManager' that failed on every attempt, or failed with
+// an error 'WithRetryClassifier' considers permanent
+type BatchError[T any] struct {
+	Items    []T
+	Attempts int
+	Err      error
+}
+
+func (e *BatchError[T]) Error() string {
+	return fmt.Sprintf("btils: batch of %d items failed after %d attempts: %s", len(e.Items), e.Attempts, e.Err)
+}
+
+func (e *BatchError[T]) Unwrap() error {
+	return e.Err
+}
+
+// Record of a processed item, see 'WithOutcomes'
+type Outcome[T any] struct {
+	Item T
+	// Same error the item's Future resolves with, nil if it succeeded
+	Err error
+	// From a worker picking the item up until it was done. Covers the whole batch with 'NewBatchThreadManager'
+	Duration time.Duration
+}
+
+func (o Outcome[T]) Succeeded() bool {
+	return o.Err == nil
+}
+
+// Panic recovered from a callback, see 'WithPanicPropagation'
+type PanicError struct {
+	Value any
+	// Stack of the panicking goroutine, as returned by debug.Stack
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("btils: callback panicked: %v", e.Value)
+}
+
+// Returns the panic value if it's an error, so errors.Is and errors.As see through the panic
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+type options struct {
+	idleTimeout time.Duration
+	itemTimeout time.Duration
+	queueSize   int
+	lifo        bool
+	maxInFlight int
+	propagate   bool
+	outcomes    int
+	retryable   func(error) bool
+	maxRuntime  time.Duration
+}
+
+type Option func(*options)
+
+// Workers exit once they haven't received an item for d. The next Feed transparently spawns them again,
+// which saves goroutines in long-lived services with sporadic traffic
+func WithIdleTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.idleTimeout = d
+	}
+}
+
+// Bounds every callback invocation to d. Once it overruns, an *ItemError wrapping ErrItemTimeout is reported on
+// 'Errors', the item counts as processed and the worker moves on to the next one.
+// Go can't kill a goroutine, so the abandoned callback keeps running in the background until it returns by itself
+func WithItemTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.itemTimeout = d
+	}
+}
+
+// Queued item along with its bookkeeping
+type task[T any] struct {
+	in     T
+	future *Future
+	// Identifies the task for 'FeedCancelable', 0 if it can't be cancelled
+	id uint64
+}
+
+// Number of items that can be queued before Feed blocks. Defaults to the number of workers
+func WithQueueSize(n int) Option {
+	return func(o *options) {
+		o.queueSize = n
+	}
+}
+
+// Workers pick up the most recently fed item first, for workloads where fresh items matter more than old ones.
+// This gives up FIFO fairness: while the pool is saturated, old items can starve until it catches up
+func WithLIFO() Option {
+	return func(o *options) {
+		o.lifo = true
+	}
+}
+
+// Limits how many items may be in flight, i.e. fed but not finished yet, independent of the number of workers.
+// Feed blocks once n items are outstanding. Mostly useful with 'NewAsyncThreadManager', where a callback returns
+// long before its item is actually done, so the worker count alone doesn't bound the outstanding work
+func WithMaxInFlight(n int) Option {
+	return func(o *options) {
+		o.maxInFlight = n
+	}
+}
+
+// Recovers panics in callbacks instead of letting them crash the process. The panicking item counts as processed,
+// an *ItemError wrapping a *PanicError is reported on 'Errors' and the worker moves on. The first panic is kept
+// and re-raised by 'Wait', or returned by 'WaitErr', so a batch where anything panicked fails loudly
+func WithPanicPropagation() Option {
+	return func(o *options) {
+		o.propagate = true
+	}
+}
+
+// Publishes an 'Outcome' for every processed item on 'Outcomes', buffered up to n. Like 'Errors', outcomes are
+// dropped instead of blocking workers once the buffer is full, so read it continuously
+func WithOutcomes(n int) Option {
+	return func(o *options) {
+		o.outcomes = max(n, 1)
+	}
+}
+
+// Only honored by 'NewBatchThreadManager'. fn decides whether a failed batch is worth retrying: errors it reports
+// as permanent dead-letter the batch straight away instead of using up the remaining attempts. Without it, every
+// error is retried
+func WithRetryClassifier(fn func(err error) bool) Option {
+	return func(o *options) {
+		o.retryable = fn
+	}
+}
+
+// Stops the pool once d has passed since the first 'Start', so a runaway job can't run forever. Callbacks
+// that are already running finish as usual, but items still queued at the deadline are taken out of the queue
+// without being processed and can be recovered with 'Unprocessed'. Feeding afterwards fails like after 'Stop'
+func WithMaxRuntime(d time.Duration) Option {
+	return func(o *options) {
+		o.maxRuntime = d
+	}
+}
 
 type ThreaderManager[T any] struct {
-	channel chan T
+	queue *queue[task[T]]
 
 	workers  int
 	callback func(in T)
+	async    func(in T, done func())
+	options  options
+
+	batch     func(batch []T) error
+	batchSize int
+	attempts  int
+
+	// Process items on the feeding goroutine, see 'NewSyncThreadManager'
+	sync bool
+
+	inFlight *Semaphore
+
+	// First panic recovered with 'WithPanicPropagation'
+	panicked Atomic[*PanicError]
 
 	counter int64
+	// Last id handed out by 'FeedCancelable'
+	lastID uint64
+	// Times the pool went from idle to busy, lets 'IsHealthy' tell a fresh batch from a stuck one
+	busied int64
+	// What 'IsHealthy' saw on its last call and when it last saw the pool make progress
+	health struct {
+		sync.Mutex
+		processed int64
+		busied    int64
+		progress  time.Time
+	}
+
+	processed int64
+	errored   int64
+	dropped   int64
+	retried   int64
+
+	mu      sync.Mutex
+	started bool
+	running int
+	// Worker goroutines that have actually started running, unlike running which counts them once spawned.
+	// Reported as 'PoolStats.Running'
+	live int64
+
+	// Closed and replaced whenever the counter drops to 0 or a worker exits
+	signal chan struct{}
+
+	errors chan error
+	// Guards sending on errors against 'CloseAndDrain' closing it, abandoned callbacks can still report afterwards
+	errorsMu     sync.Mutex
+	errorsClosed bool
+
+	outcomes chan Outcome[T]
+
+	// Fires 'WithMaxRuntime', nil until the first Start
+	deadline    *time.Timer
+	unprocessed []T
+
+	// Items fed with 'FeedAfter' that aren't due yet, guarded by mu
+	delayed delayQueue[T]
+	// Wakes up 'scheduleDelayed' whenever an item is added, nil until the first 'FeedAfter'
+	delayedWake chan struct{}
+	// Stops 'scheduleDelayed', including a dispatch that's blocked on a full queue
+	delayedCancel context.CancelFunc
 }
 
-func NewThreadManager[T any](workers int, callback func(in T)) *ThreaderManager[T] {
+func NewThreadManager[T any](workers int, callback func(in T), opts ...Option) *ThreaderManager[T] {
 	tm := &ThreaderManager[T]{
-		channel: make(chan T, workers),
-
 		workers:  workers,
 		callback: callback,
+
+		signal: make(chan struct{}),
+		errors: make(chan error, errorsBuffer),
+	}
+
+	for _, opt := range opts {
+		opt(&tm.options)
+	}
+
+	tm.queue = newQueue[task[T]](If(tm.options.queueSize > 0, tm.options.queueSize, workers))
+	tm.queue.lifo = tm.options.lifo
+
+	if tm.options.maxInFlight > 0 {
+		tm.inFlight = NewSemaphore(tm.options.maxInFlight)
 	}
+	if tm.options.outcomes > 0 {
+		tm.outcomes = make(chan Outcome[T], tm.options.outcomes)
+	}
+
+	return tm
+}
 
+// Like 'NewThreadManager', but for callbacks that hand their item off to asynchronous work and return early.
+// An item only counts as processed once its callback calls done, so IsDone, Wait and Stats reflect the real
+// completion. Calling done more than once has no effect. Combine with 'WithMaxInFlight' to bound the outstanding
+// async work. 'WithItemTimeout' isn't honored, since the pool can't tell how long the async part takes
+func NewAsyncThreadManager[T any](workers int, callback func(in T, done func()), opts ...Option) *ThreaderManager[T] {
+	tm := NewThreadManager[T](workers, nil, opts...)
+	tm.async = callback
+	return tm
+}
+
+// Like 'NewThreadManager', but workers take up to batchSize consecutive items off the queue at once and hand them
+// to callback as one batch, e.g. to write them within a single database transaction. A worker doesn't wait for a
+// batch to fill up, it takes whatever is queued right now. If callback returns an error, the whole batch is
+// considered rolled back and retried, up to attempts times in total, see 'WithRetryClassifier' to only retry
+// transient errors. With 'WithPanicPropagation', a panicking callback counts as a failed attempt as well. After that it's dead-lettered: a *BatchError holding all of its items is reported on 'Errors'
+// and the items count as processed.
+// Delivery is at-least-once: items of a failing batch are passed to callback again, so callback has to undo its
+// partial work on error (roll back) for retries to be safe. 'WithItemTimeout' isn't honored
+func NewBatchThreadManager[T any](workers, batchSize, attempts int, callback func(batch []T) error, opts ...Option) *ThreaderManager[T] {
+	tm := NewThreadManager[T](workers, nil, opts...)
+	tm.batch = callback
+	tm.batchSize = max(batchSize, 1)
+	tm.attempts = max(attempts, 1)
+	return tm
+}
+
+// Like 'NewThreadManager', but Feed processes every item right away on the calling goroutine and only returns
+// once its callback did, so tests of code using the pool are fully deterministic. Same API, but there are no
+// workers: Start does nothing, IsDone is true whenever Feed isn't running and the queue always stays empty.
+// Meant for tests, use 'NewThreadManager' in production code
+func NewSyncThreadManager[T any](callback func(in T), opts ...Option) *ThreaderManager[T] {
+	tm := NewThreadManager[T](0, callback, opts...)
+	tm.sync = true
 	return tm
 }
 
 func (tm *ThreaderManager[T]) Start() {
-	for i := 0; i < tm.workers; i++ {
-		go func() {
-			for in := range tm.channel {
-				tm.callback(in)
-				atomic.AddInt64(&tm.counter, -1)
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	tm.start()
+	for tm.running < tm.workers {
+		tm.spawn(nil)
+	}
+}
+
+// Like 'Start', but only returns once every worker it launched is actually running and about to wait for items,
+// so the first items fed aren't delayed by goroutine startup. Workers that were already running aren't waited for
+func (tm *ThreaderManager[T]) StartAndWaitReady() {
+	var ready sync.WaitGroup
+
+	tm.mu.Lock()
+	tm.start()
+	for tm.running < tm.workers {
+		ready.Add(1)
+		tm.spawn(ready.Done)
+	}
+	tm.mu.Unlock()
+
+	ready.Wait()
+}
+
+// Has to be called with tm.mu held
+func (tm *ThreaderManager[T]) start() {
+	if !tm.started && tm.options.maxRuntime > 0 {
+		tm.deadline = time.AfterFunc(tm.options.maxRuntime, tm.expire)
+	}
+	tm.started = true
+}
+
+// Closes the queue and takes out everything still in it, see 'WithMaxRuntime'
+func (tm *ThreaderManager[T]) expire() {
+	// Closing and draining separately would let workers keep picking up items in between
+	for _, t := range tm.queue.closeAndDrain() {
+		if tm.inFlight != nil {
+			tm.inFlight.Release()
+		}
+		tm.abandon(t)
+	}
+	tm.flushDelayed()
+}
+
+// Gives up on t without processing it, recording it for 'Unprocessed'. Doesn't touch tm.inFlight
+func (tm *ThreaderManager[T]) abandon(t task[T]) {
+	tm.mu.Lock()
+	tm.unprocessed = append(tm.unprocessed, t.in)
+	tm.mu.Unlock()
+
+	if t.future != nil {
+		t.future.resolve(ErrPoolStopped)
+	}
+	tm.release()
+}
+
+// Items the pool gave up on without processing them: the ones still queued when 'WithMaxRuntime' stopped the pool
+// and the ones still waiting for their 'FeedAfter' delay when it was stopped, in the order they were taken out
+func (tm *ThreaderManager[T]) Unprocessed() []T {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	return append([]T{}, tm.unprocessed...)
+}
+
+// Has to be called with tm.mu held. ready, if not nil, is called by the worker once it's running
+func (tm *ThreaderManager[T]) spawn(ready func()) {
+	tm.running++
+	go tm.work(ready)
+}
+
+// Has to be called with tm.mu held by a worker that's about to return. live drops first, so it's 0 by the time
+// anyone woken up by the running count can look at 'Stats'
+func (tm *ThreaderManager[T]) exit() {
+	atomic.AddInt64(&tm.live, -1)
+	tm.running--
+	tm.notify()
+}
+
+func (tm *ThreaderManager[T]) work(ready func()) {
+	atomic.AddInt64(&tm.live, 1)
+
+	if ready != nil {
+		ready()
+	}
+
+	for {
+		t, err := tm.next()
+		if err == errQueueCanceled {
+			// Idle for too long. Only exit if nothing was queued in the meantime, Feed respawns us otherwise
+			tm.mu.Lock()
+			if tm.queue.len() == 0 {
+				tm.exit()
+				tm.mu.Unlock()
+				return
+			}
+			tm.mu.Unlock()
+			continue
+		}
+		if err != nil {
+			tm.mu.Lock()
+			tm.exit()
+			tm.mu.Unlock()
+			return
+		}
+
+		// Only needed for the duration reported by 'WithOutcomes', time.Now is measurable on the hot path
+		var start time.Time
+		if tm.outcomes != nil {
+			start = time.Now()
+		}
+
+		if tm.batch != nil {
+			tm.processBatch(t, start)
+			continue
+		}
+
+		if tm.async != nil {
+			var once sync.Once
+			done := func(err error) {
+				once.Do(func() {
+					tm.finish(t, err, start)
+				})
 			}
-		}()
+
+			if err := tm.call(t.in, func() { tm.async(t.in, func() { done(nil) }) }); err != nil {
+				done(err)
+			}
+			continue
+		}
+
+		tm.finish(t, tm.process(t.in), start)
+	}
+}
+
+// Fills a batch starting with t from whatever is queued right now and runs it through the batch callback
+func (tm *ThreaderManager[T]) processBatch(t task[T], start time.Time) {
+	tasks := []task[T]{t}
+	for len(tasks) < tm.batchSize {
+		next, err := tm.queue.tryPop()
+		if err != nil {
+			break
+		}
+		tasks = append(tasks, next)
+	}
+
+	items := make([]T, len(tasks))
+	for i, t := range tasks {
+		items[i] = t.in
+	}
+
+	var err error
+	attempts := 0
+	for attempts < tm.attempts {
+		if attempts++; attempts > 1 {
+			atomic.AddInt64(&tm.retried, 1)
+		}
+
+		// A recovered panic counts as a failed attempt like any other error
+		if pe := tm.catch(func() { err = tm.batch(items) }); pe != nil {
+			err = pe
+		}
+		if err == nil {
+			break
+		}
+		if tm.options.retryable != nil && !tm.options.retryable(err) {
+			break
+		}
+	}
+
+	if err != nil {
+		batchErr := &BatchError[T]{Items: items, Attempts: attempts, Err: err}
+		tm.report(batchErr)
+		err = batchErr
+	}
+
+	for _, t := range tasks {
+		tm.finish(t, err, start)
+	}
+}
+
+func (tm *ThreaderManager[T]) finish(t task[T], err error, start time.Time) {
+	atomic.AddInt64(&tm.processed, 1)
+
+	if tm.outcomes != nil {
+		// Never blocks, same as 'report'
+		select {
+		case tm.outcomes <- Outcome[T]{Item: t.in, Err: err, Duration: time.Since(start)}:
+		default:
+		}
+	}
+
+	if t.future != nil {
+		t.future.resolve(err)
+	}
+	if tm.inFlight != nil {
+		tm.inFlight.Release()
+	}
+	tm.release()
+}
+
+// Decrements the counter, waking up everyone waiting for the pool to be done if it drops to 0
+func (tm *ThreaderManager[T]) release() {
+	if atomic.AddInt64(&tm.counter, -1) == 0 {
+		tm.mu.Lock()
+		tm.notify()
+		tm.mu.Unlock()
+	}
+}
+
+// Runs fn, recovering and reporting a panic as an *ItemError for in if 'WithPanicPropagation' is set
+func (tm *ThreaderManager[T]) call(in T, fn func()) error {
+	if pe := tm.catch(fn); pe != nil {
+		itemErr := &ItemError[T]{Item: in, Err: pe}
+		tm.report(itemErr)
+		return itemErr
+	}
+	return nil
+}
+
+// Runs fn, recovering a panic as a *PanicError if 'WithPanicPropagation' is set. The first one is kept for 'Wait'
+func (tm *ThreaderManager[T]) catch(fn func()) (pe *PanicError) {
+	if !tm.options.propagate {
+		fn()
+		return nil
 	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			pe = &PanicError{Value: r, Stack: debug.Stack()}
+			tm.panicked.CompareAndSwap(nil, pe)
+		}
+	}()
+
+	fn()
+	return nil
+}
+
+func (tm *ThreaderManager[T]) process(in T) error {
+	if !tm.options.propagate && tm.options.itemTimeout <= 0 {
+		// Fast path, the closure below escapes and would cost an allocation per item
+		tm.callback(in)
+		return nil
+	}
+
+	callback := func() { tm.callback(in) }
+	if tm.options.itemTimeout <= 0 {
+		return tm.call(in, callback)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- tm.call(in, callback)
+	}()
+
+	timer := time.NewTimer(tm.options.itemTimeout)
+	defer timer.Stop()
+
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+		err := &ItemError[T]{Item: in, Err: ErrItemTimeout}
+		tm.report(err)
+		return err
+	}
+}
+
+// Never blocks, the error is dropped if nobody is reading 'Errors'
+// Once 'Errors' has been closed, e.g. when a callback abandoned by 'WithItemTimeout' panics after 'CloseAndDrain',
+// the error counts as dropped
+func (tm *ThreaderManager[T]) report(err error) {
+	atomic.AddInt64(&tm.errored, 1)
+
+	tm.errorsMu.Lock()
+	defer tm.errorsMu.Unlock()
+
+	if tm.errorsClosed {
+		atomic.AddInt64(&tm.dropped, 1)
+		return
+	}
+
+	select {
+	case tm.errors <- err:
+	default:
+		atomic.AddInt64(&tm.dropped, 1)
+	}
+}
+
+// Has to be called with tm.mu held
+func (tm *ThreaderManager[T]) notify() {
+	close(tm.signal)
+	tm.signal = make(chan struct{})
+}
+
+// Blocks until cond, which is evaluated with tm.mu held, returns true. Returns false if done fires first,
+// a nil done never fires
+func (tm *ThreaderManager[T]) waitUntil(cond func() bool, done <-chan struct{}) bool {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	for !cond() {
+		signal := tm.signal
+		tm.mu.Unlock()
+		select {
+		case <-signal:
+		case <-done:
+			tm.mu.Lock()
+			return false
+		}
+		tm.mu.Lock()
+	}
+	return true
+}
+
+func (tm *ThreaderManager[T]) next() (task[T], error) {
+	if tm.options.idleTimeout <= 0 {
+		return tm.queue.pop(nil)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), tm.options.idleTimeout)
+	defer cancel()
+
+	return tm.queue.pop(ctx.Done())
 }
 
 func (tm *ThreaderManager[T]) Feed(in T) {
-	atomic.AddInt64(&tm.counter, 1)
-	tm.channel <- in
+	if tm.feed(context.Background(), task[T]{in: in}) != nil {
+		panic("btils: Feed called on a stopped ThreaderManager")
+	}
+}
+
+// Like 'Feed', but gives up once ctx is done while waiting for room in the queue, returning ctx.Err().
+// Returns ErrPoolStopped instead of panicking if the pool has been stopped
+func (tm *ThreaderManager[T]) FeedCtx(ctx context.Context, in T) error {
+	return tm.feed(ctx, task[T]{in: in})
+}
+
+// Like 'Feed', but returns a Future resolved once this specific item has been processed,
+// so callers can wait for their own item instead of the whole pool
+func (tm *ThreaderManager[T]) FeedFuture(in T) *Future {
+	f := newFuture()
+	if tm.feed(context.Background(), task[T]{in: in, future: f}) != nil {
+		panic("btils: FeedFuture called on a stopped ThreaderManager")
+	}
+	return f
+}
+
+// Like 'Feed', but returns a cancel func that takes the item back out of the queue as long as no worker has
+// picked it up yet, reporting whether it did. A cancelled item is never processed and doesn't count towards the
+// stats. accepted is false, and cancel a no-op, if the pool has been stopped
+func (tm *ThreaderManager[T]) FeedCancelable(in T) (accepted bool, cancel func() bool) {
+	id := atomic.AddUint64(&tm.lastID, 1)
+	if tm.feed(context.Background(), task[T]{in: in, id: id}) != nil {
+		return false, func() bool { return false }
+	}
+
+	return true, func() bool {
+		if !tm.queue.remove(func(t task[T]) bool { return t.id == id }) {
+			return false
+		}
+
+		if tm.inFlight != nil {
+			tm.inFlight.Release()
+		}
+		tm.release()
+		return true
+	}
+}
+
+func (tm *ThreaderManager[T]) feed(ctx context.Context, t task[T]) error {
+	if tm.inFlight != nil {
+		if err := tm.inFlight.Acquire(ctx); err != nil {
+			return err
+		}
+	}
+
+	tm.add()
+
+	if tm.sync {
+		if tm.queue.isClosed() {
+			if tm.inFlight != nil {
+				tm.inFlight.Release()
+			}
+			tm.release()
+			return ErrPoolStopped
+		}
+
+		tm.finish(t, tm.process(t.in), time.Now())
+		return nil
+	}
+	if err := tm.queue.push(t, ctx.Done()); err != nil {
+		if tm.inFlight != nil {
+			tm.inFlight.Release()
+		}
+		tm.release()
+
+		switch err {
+		case ErrQueueClosed:
+			return ErrPoolStopped
+		case errQueueCanceled:
+			return ctx.Err()
+		}
+		return err
+	}
+
+	tm.wake()
+	return nil
+}
+
+// Increments the counter for a newly fed item
+func (tm *ThreaderManager[T]) add() {
+	if atomic.AddInt64(&tm.counter, 1) == 1 {
+		// Going from idle to busy, don't hold the idle time against the pool in 'IsHealthy'
+		atomic.AddInt64(&tm.busied, 1)
+	}
+}
+
+// Respawns a worker for a freshly queued item if workers exited after 'WithIdleTimeout'
+func (tm *ThreaderManager[T]) wake() {
+	if tm.options.idleTimeout > 0 {
+		tm.mu.Lock()
+		if tm.started && tm.running < tm.workers {
+			tm.spawn(nil)
+		}
+		tm.mu.Unlock()
+	}
+}
+
+// Point-in-time view of the pool's counters, see 'Stats'
+type PoolStats struct {
+	// Items whose callback has returned or timed out
+	Processed int64
+	// Items that have been fed but not processed yet, including the ones currently being worked on
+	Pending int64
+	// Errors produced by the pool, including dropped ones
+	Errors int64
+	// Errors that couldn't be delivered because 'Errors' was full. Never larger than Errors
+	Dropped int64
+	// Worker goroutines currently alive. Ones that were spawned but haven't been scheduled yet don't count
+	Running int
+	// Attempts beyond the first one that 'NewBatchThreadManager' made for failed batches
+	Retries int64
+}
+
+// Snapshot of all counters. Plain struct, so it can be translated to whatever metrics system is in use
+func (tm *ThreaderManager[T]) Stats() PoolStats {
+	// Dropped is read before Errors since it's incremented after it, which keeps Dropped <= Errors
+	dropped := atomic.LoadInt64(&tm.dropped)
+
+	return PoolStats{
+		Processed: atomic.LoadInt64(&tm.processed),
+		Pending:   atomic.LoadInt64(&tm.counter),
+		Errors:    atomic.LoadInt64(&tm.errored),
+		Dropped:   dropped,
+		Running:   int(atomic.LoadInt64(&tm.live)),
+		Retries:   atomic.LoadInt64(&tm.retried),
+	}
+}
+
+// Errors produced by the pool itself, e.g. item timeouts. The channel is buffered and errors are dropped once it's
+// full, so read it continuously if you care about them. It's closed by 'CloseAndDrain'
+func (tm *ThreaderManager[T]) Errors() <-chan error {
+	return tm.errors
+}
+
+// Outcome of every processed item if the pool was created with 'WithOutcomes', nil otherwise.
+// It's closed by 'CloseAndDrain'
+func (tm *ThreaderManager[T]) Outcomes() <-chan Outcome[T] {
+	return tm.outcomes
 }
 
 func (tm *ThreaderManager[T]) IsDone() bool {
 	return atomic.LoadInt64(&tm.counter) == 0
 }
 
+// Liveness probe for health checks. Reports false if items are pending but none has completed within
+// stuckThreshold, e.g. because every worker is stuck in a hung callback or the pool was never started.
+// An idle pool is always healthy. Pick a threshold well above the slowest expected callback.
+// Progress is detected by comparing counters between calls rather than timestamping every item, so call it
+// periodically. A pool that got stuck is reported within stuckThreshold of the first call that saw it stuck
+func (tm *ThreaderManager[T]) IsHealthy(stuckThreshold time.Duration) bool {
+	h := &tm.health
+	h.Lock()
+	defer h.Unlock()
+
+	now := time.Now()
+	processed, busied := atomic.LoadInt64(&tm.processed), atomic.LoadInt64(&tm.busied)
+	if processed != h.processed || busied != h.busied || h.progress.IsZero() {
+		h.processed, h.busied, h.progress = processed, busied, now
+	}
+
+	if tm.IsDone() {
+		h.progress = now
+		return true
+	}
+	return now.Sub(h.progress) <= stuckThreshold
+}
+
+// Blocks until all fed items have been processed. Never returns if items are fed but the pool is never started.
+// With 'WithPanicPropagation', re-panics with the first recovered *PanicError once everything is processed
+func (tm *ThreaderManager[T]) Wait() {
+	if err := tm.WaitErr(); err != nil {
+		panic(err)
+	}
+}
+
+// Like 'Wait', but returns the first recovered *PanicError instead of re-panicking. Always nil without
+// 'WithPanicPropagation'
+func (tm *ThreaderManager[T]) WaitErr() error {
+	tm.waitUntil(tm.IsDone, nil)
+
+	if pe := tm.panicked.Load(); pe != nil {
+		return pe
+	}
+	return nil
+}
+
+// Like 'Wait', but returns ctx.Err() if ctx is done before all fed items have been processed
+func (tm *ThreaderManager[T]) WaitCtx(ctx context.Context) error {
+	if !tm.waitUntil(tm.IsDone, ctx.Done()) {
+		return ctx.Err()
+	}
+	return nil
+}
+
+// Returns a copy of the items that are queued but have not been picked up by a worker yet, oldest first.
+// The snapshot is point-in-time and may already be stale once it's returned, so only use it for diagnostics
+func (tm *ThreaderManager[T]) Snapshot() []T {
+	tasks := tm.queue.snapshot()
+
+	res := make([]T, len(tasks))
+	for i, t := range tasks {
+		res[i] = t.in
+	}
+	return res
+}
+
+// Number of items the queue can hold before Feed blocks
+func (tm *ThreaderManager[T]) QueueCapacity() int {
+	return tm.queue.cap()
+}
+
+// Number of items currently queued. This is an instantaneous value that may change right after it's read,
+// so only use it for heuristics like backing off producers
+func (tm *ThreaderManager[T]) QueueLen() int {
+	return tm.queue.len()
+}
+
 func (tm *ThreaderManager[T]) Stop() {
-	close(tm.channel)
+	tm.queue.close()
+	tm.stopDeadline()
+	tm.flushDelayed()
+}
+
+func (tm *ThreaderManager[T]) stopDeadline() {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	if tm.deadline != nil {
+		tm.deadline.Stop()
+	}
+}
+
+// Stops accepting new items, waits for everything already fed to be processed and returns once every worker has
+// exited, closing 'Errors' and 'Outcomes' exactly once. Safe to call multiple times and in combination with 'Stop'.
+// Feeding afterwards panics, same as after 'Stop'
+func (tm *ThreaderManager[T]) CloseAndDrain() {
+	tm.queue.close()
+	tm.stopDeadline()
+	tm.flushDelayed()
+	tm.waitUntil(tm.IsDone, nil)
+	tm.waitUntil(func() bool {
+		return tm.running == 0
+	}, nil)
+
+	tm.errorsMu.Lock()
+	defer tm.errorsMu.Unlock()
+
+	if !tm.errorsClosed {
+		tm.errorsClosed = true
+		close(tm.errors)
+		if tm.outcomes != nil {
+			close(tm.outcomes)
+		}
+	}
 }
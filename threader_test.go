@@ -0,0 +1,834 @@
+package btils
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/41Baloo/btils/btilstest"
+)
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	btilstest.Eventually(t, cond, 5*time.Second)
+}
+
+func TestSnapshot(t *testing.T) {
+	release := make(chan struct{})
+	tm := NewThreadManager[string](1, func(in string) {
+		<-release
+	})
+
+	tm.Start()
+	defer tm.Stop()
+
+	tm.Feed("Foo")
+	waitFor(t, func() bool { return len(tm.Snapshot()) == 0 })
+
+	tm.Feed("Baar")
+
+	snap := tm.Snapshot()
+	if len(snap) != 1 || snap[0] != "Baar" {
+		t.Fatalf("expected [Baar], got %v", snap)
+	}
+
+	// The snapshot is a copy, modifying it must not touch the queue
+	snap[0] = "Baloo"
+	if tm.Snapshot()[0] != "Baar" {
+		t.Fatal("snapshot aliases the internal queue")
+	}
+
+	close(release)
+	waitFor(t, tm.IsDone)
+
+	if len(tm.Snapshot()) != 0 {
+		t.Fatal("expected an empty snapshot once done")
+	}
+}
+
+func TestIdleTimeout(t *testing.T) {
+	var handled atomic.Int64
+	tm := NewThreadManager[string](2, func(in string) {
+		handled.Add(1)
+	}, WithIdleTimeout(20*time.Millisecond))
+
+	running := func() int {
+		tm.mu.Lock()
+		defer tm.mu.Unlock()
+		return tm.running
+	}
+
+	tm.Start()
+	defer tm.Stop()
+
+	if running() != 2 {
+		t.Fatalf("expected 2 running workers after Start, got %d", running())
+	}
+
+	waitFor(t, func() bool { return running() == 0 })
+
+	tm.Feed("Foo")
+	waitFor(t, tm.IsDone)
+
+	if handled.Load() != 1 {
+		t.Fatalf("expected the item fed after idling to be handled, got %d", handled.Load())
+	}
+
+	waitFor(t, func() bool { return running() == 0 })
+}
+
+func TestCloseAndDrain(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	var handled atomic.Int64
+	tm := NewThreadManager[int](4, func(in int) {
+		time.Sleep(time.Millisecond)
+		handled.Add(1)
+	})
+
+	tm.Start()
+	for i := 0; i < 100; i++ {
+		tm.Feed(i)
+	}
+
+	tm.CloseAndDrain()
+	tm.CloseAndDrain()
+
+	if handled.Load() != 100 {
+		t.Fatalf("expected 100 handled items, got %d", handled.Load())
+	}
+	if !tm.IsDone() {
+		t.Fatal("expected the pool to be done after CloseAndDrain")
+	}
+
+	// Workers have exited by the time CloseAndDrain returns, give the runtime a moment to reap them
+	waitFor(t, func() bool { return runtime.NumGoroutine() <= before })
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected Feed after CloseAndDrain to panic")
+		}
+	}()
+	tm.Feed(0)
+}
+
+func TestWait(t *testing.T) {
+	var handled atomic.Int64
+	tm := NewThreadManager[int](2, func(in int) {
+		time.Sleep(5 * time.Millisecond)
+		handled.Add(1)
+	})
+
+	tm.Start()
+	defer tm.Stop()
+
+	for i := 0; i < 10; i++ {
+		tm.Feed(i)
+	}
+	tm.Wait()
+
+	if handled.Load() != 10 {
+		t.Fatalf("expected 10 handled items after Wait, got %d", handled.Load())
+	}
+}
+
+func TestAbandonedPanicAfterCloseAndDrain(t *testing.T) {
+	release := make(chan struct{})
+	tm := NewThreadManager[int](1, func(in int) {
+		<-release
+		panic("Foo")
+	}, WithItemTimeout(10*time.Millisecond), WithPanicPropagation())
+
+	tm.Start()
+	tm.Feed(0)
+	tm.CloseAndDrain()
+
+	// The abandoned callback panics once Errors is already closed
+	close(release)
+	waitFor(t, func() bool { return tm.Stats().Errors == 2 })
+
+	if stats := tm.Stats(); stats.Dropped != 1 {
+		t.Fatalf("expected the late error to be dropped, got %+v", stats)
+	}
+}
+
+func TestItemTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	tm := NewThreadManager[string](1, func(in string) {
+		if in == "slow" {
+			<-release
+		}
+	}, WithItemTimeout(20*time.Millisecond))
+
+	tm.Start()
+	tm.Feed("slow")
+	tm.Feed("fast")
+	tm.Wait()
+
+	err := <-tm.Errors()
+
+	var itemErr *ItemError[string]
+	if !errors.As(err, &itemErr) || itemErr.Item != "slow" {
+		t.Fatalf("expected an ItemError for the slow item, got %v", err)
+	}
+	if !errors.Is(err, ErrItemTimeout) {
+		t.Fatalf("expected ErrItemTimeout, got %v", err)
+	}
+
+	tm.CloseAndDrain()
+	if _, ok := <-tm.Errors(); ok {
+		t.Fatal("expected Errors to be closed after CloseAndDrain")
+	}
+}
+
+func TestQueueLen(t *testing.T) {
+	tm := NewThreadManager[int](2, func(in int) {})
+
+	if tm.QueueCapacity() != 2 {
+		t.Fatalf("expected a capacity of 2, got %d", tm.QueueCapacity())
+	}
+
+	// Not started, so items stay queued
+	tm.Feed(1)
+	tm.Feed(2)
+	if tm.QueueLen() != 2 {
+		t.Fatalf("expected 2 queued items, got %d", tm.QueueLen())
+	}
+
+	tm.Start()
+	tm.CloseAndDrain()
+	if tm.QueueLen() != 0 {
+		t.Fatalf("expected an empty queue, got %d", tm.QueueLen())
+	}
+}
+
+func TestQueueCloseAndDrain(t *testing.T) {
+	q := newQueue[int](3)
+	for i := 0; i < 3; i++ {
+		q.tryPush(i)
+	}
+	// Wrap around the ring buffer so draining has to follow head
+	q.tryPop()
+	q.tryPush(3)
+
+	drained := q.closeAndDrain()
+	if len(drained) != 3 || drained[0] != 1 || drained[2] != 3 {
+		t.Fatalf("expected [1 2 3], got %v", drained)
+	}
+	if _, err := q.pop(nil); !errors.Is(err, ErrQueueClosed) {
+		t.Fatalf("expected ErrQueueClosed once drained, got %v", err)
+	}
+	if q.len() != 0 || !q.isClosed() {
+		t.Fatalf("expected an empty closed queue, got %d items", q.len())
+	}
+}
+
+func TestStats(t *testing.T) {
+	tm := NewThreadManager[int](2, func(in int) {
+		if in%10 == 0 {
+			time.Sleep(50 * time.Millisecond)
+		}
+	}, WithItemTimeout(10*time.Millisecond))
+
+	tm.Start()
+	for i := 0; i < 100; i++ {
+		tm.Feed(i)
+
+		stats := tm.Stats()
+		if stats.Pending < 0 || stats.Processed > int64(i+1) || stats.Dropped > stats.Errors {
+			t.Fatalf("inconsistent stats %+v after feeding %d items", stats, i+1)
+		}
+	}
+	tm.Wait()
+
+	stats := tm.Stats()
+	if stats.Processed != 100 || stats.Pending != 0 {
+		t.Fatalf("unexpected stats after Wait %+v", stats)
+	}
+	if stats.Errors != 10 {
+		t.Fatalf("expected 10 timeouts, got %+v", stats)
+	}
+
+	tm.CloseAndDrain()
+	if stats := tm.Stats(); stats.Running != 0 {
+		t.Fatalf("expected no running workers, got %+v", stats)
+	}
+}
+
+func TestFeedCtx(t *testing.T) {
+	release := make(chan struct{})
+	tm := NewThreadManager[int](1, func(in int) {
+		<-release
+	})
+
+	tm.Start()
+
+	tm.Feed(1)
+	waitFor(t, func() bool { return tm.QueueLen() == 0 })
+	tm.Feed(2) // The queue is full from here on
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if err := tm.FeedCtx(ctx, 3); err != context.DeadlineExceeded {
+		t.Fatalf("expected DeadlineExceeded, got %v", err)
+	}
+	if stats := tm.Stats(); stats.Pending != 2 {
+		t.Fatalf("expected the cancelled item to not be counted, got %+v", stats)
+	}
+
+	if err := tm.WaitCtx(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("expected WaitCtx to give up, got %v", err)
+	}
+
+	close(release)
+	if err := tm.WaitCtx(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	tm.CloseAndDrain()
+	if err := tm.FeedCtx(context.Background(), 4); err != ErrPoolStopped {
+		t.Fatalf("expected ErrPoolStopped, got %v", err)
+	}
+}
+
+func TestFeedFuture(t *testing.T) {
+	tm := NewThreadManager[time.Duration](4, func(in time.Duration) {
+		time.Sleep(in)
+	}, WithItemTimeout(200*time.Millisecond))
+
+	tm.Start()
+	defer tm.CloseAndDrain()
+
+	slow := tm.FeedFuture(100 * time.Millisecond)
+	fast := tm.FeedFuture(time.Millisecond)
+	timeout := tm.FeedFuture(time.Second)
+
+	if err := fast.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-slow.Done():
+		t.Fatal("expected the slow future to still be pending once the fast one resolved")
+	default:
+	}
+
+	if err := slow.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if err := timeout.Wait(); !errors.Is(err, ErrItemTimeout) {
+		t.Fatalf("expected a timeout error, got %v", err)
+	}
+	if timeout.Err() != timeout.Wait() {
+		t.Fatal("expected Err to match Wait once resolved")
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := tm.FeedFuture(time.Millisecond).Wait(); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestLIFO(t *testing.T) {
+	release := make(chan struct{})
+
+	var mu sync.Mutex
+	var order []int
+	tm := NewThreadManager[int](1, func(in int) {
+		if in == 0 {
+			<-release
+		}
+		mu.Lock()
+		order = append(order, in)
+		mu.Unlock()
+	}, WithLIFO(), WithQueueSize(10))
+
+	if tm.QueueCapacity() != 10 {
+		t.Fatalf("expected a capacity of 10, got %d", tm.QueueCapacity())
+	}
+
+	tm.Start()
+	defer tm.Stop()
+
+	tm.Feed(0)
+	waitFor(t, func() bool { return tm.QueueLen() == 0 })
+	for i := 1; i <= 5; i++ {
+		tm.Feed(i)
+	}
+
+	close(release)
+	tm.Wait()
+
+	if expected := []int{0, 5, 4, 3, 2, 1}; !SliceEqual(order, expected) {
+		t.Fatalf("expected %v, got %v", expected, order)
+	}
+}
+
+func TestMaxInFlight(t *testing.T) {
+	var outstanding, peak, finished atomic.Int32
+	tm := NewAsyncThreadManager[int](1, func(in int, done func()) {
+		n := outstanding.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			outstanding.Add(-1)
+			finished.Add(1)
+			done()
+			done() // Must be harmless
+		}()
+	}, WithMaxInFlight(3))
+
+	tm.Start()
+	defer tm.Stop()
+
+	for i := 0; i < 12; i++ {
+		tm.Feed(i)
+	}
+	tm.Wait()
+
+	if finished.Load() != 12 {
+		t.Fatalf("expected Wait to return after all 12 async completions, got %d", finished.Load())
+	}
+	if peak.Load() > 3 {
+		t.Fatalf("expected at most 3 items in flight, got %d", peak.Load())
+	}
+	if stats := tm.Stats(); stats.Processed != 12 || stats.Pending != 0 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+}
+
+func TestPanicPropagation(t *testing.T) {
+	var handled atomic.Int64
+	tm := NewThreadManager[int](2, func(in int) {
+		if in == 3 {
+			panic("Foo")
+		}
+		handled.Add(1)
+	}, WithPanicPropagation())
+
+	tm.Start()
+	defer tm.Stop()
+
+	for i := 0; i < 10; i++ {
+		tm.Feed(i)
+	}
+
+	err := tm.WaitErr()
+
+	var pe *PanicError
+	if !errors.As(err, &pe) || pe.Value != "Foo" {
+		t.Fatalf("expected a PanicError for Foo, got %v", err)
+	}
+	if !bytes.Contains(pe.Stack, []byte("TestPanicPropagation")) {
+		t.Fatalf("expected the stack to point at the panicking callback, got %s", pe.Stack)
+	}
+	if handled.Load() != 9 {
+		t.Fatalf("expected the workers to keep going after the panic, got %d handled", handled.Load())
+	}
+
+	var itemErr *ItemError[int]
+	if !errors.As(<-tm.Errors(), &itemErr) || itemErr.Item != 3 {
+		t.Fatalf("expected the panic to be reported for item 3, got %v", itemErr)
+	}
+
+	defer func() {
+		if r := recover(); r != pe {
+			t.Fatalf("expected Wait to re-panic with the PanicError, got %v", r)
+		}
+	}()
+	tm.Wait()
+}
+
+func TestBatchThreadManager(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	var mu sync.Mutex
+	var committed []int
+	attempts := map[int]int{}
+
+	tm := NewBatchThreadManager[int](1, 3, 2, func(batch []int) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		// Stage the writes like a transaction would and only commit if every item succeeded
+		var tx []int
+		for _, in := range batch {
+			attempts[in]++
+			if in == 4 || (in == 7 && attempts[in] == 1) {
+				return errBoom // Rollback, tx is discarded
+			}
+			tx = append(tx, in)
+		}
+		committed = append(committed, tx...)
+		return nil
+	}, WithQueueSize(9))
+
+	// Feed everything before starting, so the batches are [0 1 2] [3 4 5] [6 7 8]
+	for i := 0; i < 9; i++ {
+		tm.Feed(i)
+	}
+	tm.Start()
+	tm.Wait()
+
+	if expected := []int{0, 1, 2, 6, 7, 8}; !SliceEqual(committed, expected) {
+		t.Fatalf("expected %v to be committed, got %v", expected, committed)
+	}
+	if attempts[3] != 2 || attempts[6] != 2 {
+		t.Fatalf("expected failing batches to be retried as a whole, got %v", attempts)
+	}
+
+	var batchErr *BatchError[int]
+	if err := <-tm.Errors(); !errors.As(err, &batchErr) || !errors.Is(err, errBoom) {
+		t.Fatalf("expected a BatchError wrapping boom, got %v", err)
+	}
+	if len(batchErr.Items) != 3 || batchErr.Items[0] != 3 || batchErr.Attempts != 2 {
+		t.Fatalf("expected the whole batch to be dead-lettered, got %+v", batchErr)
+	}
+
+	if stats := tm.Stats(); stats.Processed != 9 || stats.Errors != 1 || stats.Retries != 2 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+	tm.CloseAndDrain()
+}
+
+func TestBatchThreadManagerPanic(t *testing.T) {
+	var mu sync.Mutex
+	calls := 0
+
+	tm := NewBatchThreadManager[int](1, 2, 3, func(batch []int) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if calls++; calls == 1 {
+			panic("Foo")
+		}
+		return nil
+	}, WithPanicPropagation(), WithQueueSize(2))
+
+	tm.Feed(0)
+	tm.Feed(1)
+	tm.Start()
+
+	var pe *PanicError
+	if err := tm.WaitErr(); !errors.As(err, &pe) || pe.Value != "Foo" {
+		t.Fatalf("expected the batch panic to be recovered, got %v", err)
+	}
+	if calls != 2 {
+		t.Fatalf("expected the panic to count as a failed attempt and be retried, got %d calls", calls)
+	}
+	if stats := tm.Stats(); stats.Processed != 2 || stats.Errors != 0 || stats.Retries != 1 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+	tm.CloseAndDrain()
+}
+
+func TestIsHealthy(t *testing.T) {
+	release := make(chan struct{})
+	tm := NewThreadManager[int](1, func(in int) {
+		if in == 0 {
+			<-release
+		}
+	}, WithQueueSize(4))
+
+	tm.Start()
+	defer tm.Stop()
+
+	if !tm.IsHealthy(time.Millisecond) {
+		t.Fatal("expected an idle pool to be healthy")
+	}
+
+	// Idling longer than the threshold must not count against the next batch
+	time.Sleep(20 * time.Millisecond)
+	tm.Feed(1)
+	tm.Feed(0)
+	tm.Feed(2)
+	if !tm.IsHealthy(10 * time.Millisecond) {
+		t.Fatal("expected a pool that just became busy to be healthy")
+	}
+
+	waitFor(t, func() bool { return !tm.IsHealthy(10 * time.Millisecond) })
+	if !tm.IsHealthy(time.Hour) {
+		t.Fatal("expected a generous threshold to still consider the pool healthy")
+	}
+
+	close(release)
+	tm.Wait()
+	if !tm.IsHealthy(time.Millisecond) {
+		t.Fatal("expected the pool to be healthy again once it caught up")
+	}
+}
+
+func TestOutcomes(t *testing.T) {
+	tm := NewThreadManager[int](4, func(in int) {
+		if in%3 == 0 {
+			panic("Foo")
+		}
+	}, WithPanicPropagation(), WithOutcomes(100))
+
+	tm.Start()
+	for i := 0; i < 30; i++ {
+		tm.Feed(i)
+	}
+	tm.CloseAndDrain()
+
+	seen := map[int]int{}
+	for outcome := range tm.Outcomes() {
+		seen[outcome.Item]++
+
+		if outcome.Succeeded() != (outcome.Item%3 != 0) {
+			t.Fatalf("unexpected outcome %+v", outcome)
+		}
+		if !outcome.Succeeded() && !errors.As(outcome.Err, new(*PanicError)) {
+			t.Fatalf("expected a PanicError for item %d, got %v", outcome.Item, outcome.Err)
+		}
+		if outcome.Duration < 0 {
+			t.Fatalf("unexpected duration %+v", outcome)
+		}
+	}
+
+	for i := 0; i < 30; i++ {
+		if seen[i] != 1 {
+			t.Fatalf("expected exactly one outcome per item, got %v", seen)
+		}
+	}
+}
+
+func TestOutcomesDoNotBlock(t *testing.T) {
+	tm := NewThreadManager[int](1, func(in int) {}, WithOutcomes(2))
+	if NewThreadManager[int](1, func(in int) {}).Outcomes() != nil {
+		t.Fatal("expected no outcomes without WithOutcomes")
+	}
+
+	tm.Start()
+	for i := 0; i < 10; i++ {
+		tm.Feed(i)
+	}
+	tm.Wait() // Would hang if workers blocked on the full buffer
+	tm.CloseAndDrain()
+
+	if outcomes := CollectAll(tm.Outcomes()); len(outcomes) != 2 || outcomes[0].Item != 0 {
+		t.Fatalf("expected the first 2 outcomes to be buffered, got %v", outcomes)
+	}
+}
+
+func TestSyncThreadManager(t *testing.T) {
+	// No locking on purpose, everything runs on the test goroutine
+	var handled []string
+	tm := NewSyncThreadManager[string](func(in string) {
+		time.Sleep(10 * time.Millisecond)
+		handled = append(handled, in)
+	})
+
+	tm.Start()
+
+	tm.Feed("Foo")
+	if len(handled) != 1 || !tm.IsDone() {
+		t.Fatalf("expected Feed to return only once the callback did, got %v", handled)
+	}
+
+	f := tm.FeedFuture("Baar")
+	select {
+	case <-f.Done():
+	default:
+		t.Fatal("expected the future to be resolved by the time FeedFuture returns")
+	}
+
+	tm.Wait()
+	if !SliceEqual(handled, []string{"Foo", "Baar"}) {
+		t.Fatalf("unexpected order %v", handled)
+	}
+	if stats := tm.Stats(); stats.Processed != 2 || stats.Running != 0 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+
+	tm.CloseAndDrain()
+	if err := tm.FeedCtx(context.Background(), "Baloo"); err != ErrPoolStopped {
+		t.Fatalf("expected ErrPoolStopped, got %v", err)
+	}
+	if stats := tm.Stats(); stats.Pending != 0 {
+		t.Fatalf("expected the rejected item to not be counted, got %+v", stats)
+	}
+}
+
+func TestStartAndWaitReady(t *testing.T) {
+	var handled atomic.Int64
+	tm := NewThreadManager[int](8, func(in int) {
+		handled.Add(1)
+	})
+
+	tm.StartAndWaitReady()
+	defer tm.Stop()
+
+	if running := tm.Stats().Running; running != 8 {
+		t.Fatalf("expected all 8 workers to be running once StartAndWaitReady returns, got %d", running)
+	}
+
+	// Already running, nothing to wait for
+	tm.StartAndWaitReady()
+
+	for i := 0; i < 10; i++ {
+		tm.Feed(i)
+	}
+	tm.Wait()
+	if handled.Load() != 10 {
+		t.Fatalf("expected 10 handled items, got %d", handled.Load())
+	}
+}
+
+func TestRetryClassifier(t *testing.T) {
+	errTransient := errors.New("transient")
+	errPermanent := errors.New("permanent")
+
+	var mu sync.Mutex
+	attempts := map[int]int{}
+	tm := NewBatchThreadManager[int](1, 1, 3, func(batch []int) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		attempts[batch[0]]++
+		if batch[0] == 0 {
+			return errPermanent
+		}
+		if attempts[batch[0]] < 3 {
+			return errTransient
+		}
+		return nil
+	}, WithRetryClassifier(func(err error) bool {
+		return !errors.Is(err, errPermanent)
+	}))
+
+	tm.Start()
+	tm.Feed(0)
+	tm.Feed(1)
+	tm.CloseAndDrain()
+
+	if attempts[0] != 1 {
+		t.Fatalf("expected the permanent error to not be retried, got %d attempts", attempts[0])
+	}
+	if attempts[1] != 3 {
+		t.Fatalf("expected the transient error to be retried until it succeeded, got %d attempts", attempts[1])
+	}
+
+	errs := CollectAll(tm.Errors())
+	var batchErr *BatchError[int]
+	if len(errs) != 1 || !errors.As(errs[0], &batchErr) || batchErr.Attempts != 1 || batchErr.Items[0] != 0 {
+		t.Fatalf("expected only the permanent failure to be dead-lettered after 1 attempt, got %v", errs)
+	}
+}
+
+func TestFeedCancelable(t *testing.T) {
+	release := make(chan struct{})
+
+	var mu sync.Mutex
+	var handled []string
+	tm := NewThreadManager[string](1, func(in string) {
+		if in == "Foo" {
+			<-release
+		}
+		mu.Lock()
+		handled = append(handled, in)
+		mu.Unlock()
+	}, WithQueueSize(4))
+
+	tm.Start()
+	defer tm.Stop()
+
+	_, cancelStarted := tm.FeedCancelable("Foo")
+	waitFor(t, func() bool { return tm.QueueLen() == 0 })
+
+	_, cancelQueued := tm.FeedCancelable("Baar")
+	tm.Feed("Baloo")
+
+	if cancelStarted() {
+		t.Fatal("expected an item that's already being processed to not be cancellable")
+	}
+	if !cancelQueued() {
+		t.Fatal("expected a queued item to be cancellable")
+	}
+	if cancelQueued() {
+		t.Fatal("expected cancelling twice to fail")
+	}
+
+	close(release)
+	tm.Wait()
+
+	if !SliceEqual(handled, []string{"Foo", "Baloo"}) {
+		t.Fatalf("expected the cancelled item to be skipped, got %v", handled)
+	}
+	if stats := tm.Stats(); stats.Processed != 2 || stats.Pending != 0 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+
+	tm.CloseAndDrain()
+	if accepted, cancel := tm.FeedCancelable("Golang"); accepted || cancel() {
+		t.Fatal("expected a stopped pool to not accept the item")
+	}
+}
+
+func TestMaxRuntime(t *testing.T) {
+	tm := NewThreadManager[int](1, func(in int) {
+		time.Sleep(20 * time.Millisecond)
+	}, WithQueueSize(50), WithMaxRuntime(100*time.Millisecond))
+
+	for i := 0; i < 50; i++ {
+		tm.Feed(i)
+	}
+
+	start := time.Now()
+	tm.Start()
+	tm.Wait()
+
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("expected the pool to stop near the deadline, took %v", elapsed)
+	}
+
+	processed := tm.Stats().Processed
+	remaining := tm.Unprocessed()
+	if processed == 0 || len(remaining) == 0 {
+		t.Fatalf("expected the deadline to interrupt the run, processed %d with %d remaining", processed, len(remaining))
+	}
+	if processed+int64(len(remaining)) != 50 {
+		t.Fatalf("expected every item to be either processed or remaining, got %d + %d", processed, len(remaining))
+	}
+	if remaining[len(remaining)-1] != 49 {
+		t.Fatalf("expected the remaining items in feed order, got %v", remaining)
+	}
+
+	if err := tm.FeedCtx(context.Background(), 50); !errors.Is(err, ErrPoolStopped) {
+		t.Fatalf("expected ErrPoolStopped after the deadline, got %v", err)
+	}
+	tm.CloseAndDrain()
+}
+
+func BenchmarkThreaderManager(b *testing.B) {
+	tm := NewThreadManager[int](4, func(in int) {})
+	tm.Start()
+	defer tm.Stop()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tm.Feed(i)
+	}
+	tm.Wait()
+}
@@ -0,0 +1,108 @@
+package btils
+
+import (
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// Goroutine-safe cache-aside store whose entries expire after a per-entry time-to-live. Concurrent misses for the
+// same key are coalesced, so the loader only runs once and everyone waiting gets its result.
+// Expired entries are only replaced when requested again, there is no background sweeping
+type TTLCache[K comparable, V any] struct {
+	mu      sync.Mutex
+	entries map[K]ttlEntry[V]
+	loading map[K]*ttlLoad[V]
+
+	now func() time.Time
+}
+
+type ttlEntry[V any] struct {
+	value   V
+	expires time.Time
+}
+
+type ttlLoad[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+// Not generic over the cache, so options work without spelling out its type parameters
+type TTLCacheOption func(*ttlCacheOptions)
+
+type ttlCacheOptions struct {
+	now func() time.Time
+}
+
+// Replaces the clock ttls are measured with, e.g. with a fake one in tests. Defaults to time.Now
+func WithCacheClock(now func() time.Time) TTLCacheOption {
+	return func(o *ttlCacheOptions) {
+		o.now = now
+	}
+}
+
+func NewTTLCache[K comparable, V any](opts ...TTLCacheOption) *TTLCache[K, V] {
+	o := ttlCacheOptions{now: time.Now}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return &TTLCache[K, V]{
+		entries: make(map[K]ttlEntry[V]),
+		loading: make(map[K]*ttlLoad[V]),
+		now:     o.now,
+	}
+}
+
+// Returns the cached value for key, or calls loader if it's missing or expired and caches the result for ttl.
+// Errors from loader are returned to every waiting caller and are not cached. If loader panics, the panic is
+// re-raised in this call while every waiting caller gets a *PanicError, and the next Get tries again
+func (c *TTLCache[K, V]) Get(key K, loader func() (V, error), ttl time.Duration) (V, error) {
+	c.mu.Lock()
+
+	if e, ok := c.entries[key]; ok && c.now().Before(e.expires) {
+		c.mu.Unlock()
+		return e.value, nil
+	}
+
+	if l, ok := c.loading[key]; ok {
+		c.mu.Unlock()
+		<-l.done
+		return l.value, l.err
+	}
+
+	l := &ttlLoad[V]{done: make(chan struct{})}
+	c.loading[key] = l
+	c.mu.Unlock()
+
+	// Deferred, so a panicking loader doesn't leave everyone waiting for this key blocked forever
+	defer func() {
+		r := recover()
+		if r != nil {
+			l.err = &PanicError{Value: r, Stack: debug.Stack()}
+		}
+
+		c.mu.Lock()
+		delete(c.loading, key)
+		if l.err == nil {
+			c.entries[key] = ttlEntry[V]{value: l.value, expires: c.now().Add(ttl)}
+		}
+		c.mu.Unlock()
+
+		close(l.done)
+		if r != nil {
+			panic(r)
+		}
+	}()
+
+	l.value, l.err = loader()
+	return l.value, l.err
+}
+
+func (c *TTLCache[K, V]) Delete(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, key)
+}
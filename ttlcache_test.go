@@ -0,0 +1,98 @@
+package btils
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTTLCacheExpiry(t *testing.T) {
+	now := time.Unix(0, 0)
+	c := NewTTLCache[string, int](WithCacheClock(func() time.Time { return now }))
+
+	loads := 0
+	loader := func() (int, error) {
+		loads++
+		return loads, nil
+	}
+
+	if v, _ := c.Get("Foo", loader, time.Minute); v != 1 {
+		t.Fatalf("expected 1, got %d", v)
+	}
+
+	now = now.Add(59 * time.Second)
+	if v, _ := c.Get("Foo", loader, time.Minute); v != 1 {
+		t.Fatalf("expected the cached 1, got %d", v)
+	}
+
+	now = now.Add(time.Second)
+	if v, _ := c.Get("Foo", loader, time.Minute); v != 2 {
+		t.Fatalf("expected a reload after expiry, got %d", v)
+	}
+}
+
+func TestTTLCacheCoalescing(t *testing.T) {
+	c := NewTTLCache[string, int]()
+
+	var loads atomic.Int32
+	release := make(chan struct{})
+	loader := func() (int, error) {
+		loads.Add(1)
+		<-release
+		return 42, nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v, err := c.Get("Foo", loader, time.Minute); v != 42 || err != nil {
+				t.Errorf("unexpected result %d %v", v, err)
+			}
+		}()
+	}
+
+	waitFor(t, func() bool { return loads.Load() == 1 })
+	time.Sleep(10 * time.Millisecond) // Let the other goroutines pile up behind the load
+	close(release)
+	wg.Wait()
+
+	if loads.Load() != 1 {
+		t.Fatalf("expected a single load, got %d", loads.Load())
+	}
+}
+
+func TestTTLCacheError(t *testing.T) {
+	c := NewTTLCache[string, int]()
+	errBoom := errors.New("boom")
+
+	if _, err := c.Get("Foo", func() (int, error) { return 0, errBoom }, time.Minute); err != errBoom {
+		t.Fatalf("expected the loader error, got %v", err)
+	}
+
+	// Errors aren't cached
+	if v, err := c.Get("Foo", func() (int, error) { return 1, nil }, time.Minute); v != 1 || err != nil {
+		t.Fatalf("expected a fresh load, got %d %v", v, err)
+	}
+}
+
+func TestTTLCacheLoaderPanic(t *testing.T) {
+	c := NewTTLCache[string, int]()
+
+	func() {
+		defer func() {
+			if r := recover(); r != "Foo" {
+				t.Fatalf("expected the loader panic to be re-raised, got %v", r)
+			}
+		}()
+		c.Get("Foo", func() (int, error) { panic("Foo") }, time.Minute)
+	}()
+
+	// Would block forever if the panicking load was still registered
+	if v, err := c.Get("Foo", func() (int, error) { return 1, nil }, time.Minute); v != 1 || err != nil {
+		t.Fatalf("expected a fresh load, got %d %v", v, err)
+	}
+}
@@ -0,0 +1,33 @@
+package btils
+
+// Like 'NewUID', but the 16th character is a check character over the first 15, see 'ValidateChecksum'.
+// This trades 6 bits of entropy for integrity, leaving a keyspace of 2^90 instead of 2^96, so collisions become
+// likely 8 times earlier. Checksummed UIDs are still regular UIDs and can be used anywhere a UID is expected
+func NewChecksummedUID(b *UID) {
+	NewUID(b)
+	b[15] = randChars[uidChecksum(b)]
+}
+
+// Reports whether the 16th character of uid matches the check character over the first 15, i.e. uid was created
+// by 'NewChecksummedUID' and hasn't been corrupted since. The check character is the weighted sum of the alphabet
+// positions of the first 15 characters mod 64, with the odd weights 1, 3, 5, ... 29. Since every weight is odd,
+// changing any single character always changes the sum, so every single-character corruption is detected. Swapping
+// two adjacent characters is detected unless their positions differ by exactly 32. Anything beyond that is only
+// caught with a probability of 63/64, this is a guard against accidents, not against tampering
+func ValidateChecksum(uid UID) bool {
+	sum := uidChecksum(&uid)
+	return sum >= 0 && randChars[sum] == uid[15]
+}
+
+// Check character position for the first 15 characters of uid, -1 if any of them isn't part of the alphabet
+func uidChecksum(uid *UID) int {
+	var sum int
+	for i := 0; i < 15; i++ {
+		idx := randCharIndex[uid[i]]
+		if idx < 0 {
+			return -1
+		}
+		sum += int(idx) * (2*i + 1)
+	}
+	return sum & 63
+}
@@ -0,0 +1,47 @@
+package btils
+
+import "iter"
+
+// Yields n freshly generated UIDs, e.g. `for uid := range btils.UIDSeq(10)`
+func UIDSeq(n int) iter.Seq[UID] {
+	return func(yield func(UID) bool) {
+		var uid UID
+		for i := 0; i < n; i++ {
+			NewUID(&uid)
+			if !yield(uid) {
+				return
+			}
+		}
+	}
+}
+
+// Yields freshly generated UIDs until the caller breaks out of the loop
+func UIDSeqInfinite() iter.Seq[UID] {
+	return func(yield func(UID) bool) {
+		var uid UID
+		for {
+			NewUID(&uid)
+			if !yield(uid) {
+				return
+			}
+		}
+	}
+}
+
+// Lazily yields only the first occurrence of every UID in seq. The UIDs seen so far are kept in memory until the
+// loop ends
+func DedupUIDSeq(seq iter.Seq[UID]) iter.Seq[UID] {
+	return func(yield func(UID) bool) {
+		seen := make(map[UID]struct{})
+		for uid := range seq {
+			if _, ok := seen[uid]; ok {
+				continue
+			}
+			seen[uid] = struct{}{}
+
+			if !yield(uid) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package btils
+
+import "math"
+
+// Per-position character frequencies of generated UIDs, see 'DistributionTest'
+type UIDDistribution struct {
+	Samples int
+	// Counts[position][i] is how often randChars[i] was generated at that position
+	Counts [16][64]int
+}
+
+// Generates samples UIDs using 'NewUID' and counts how often each alphabet character appears at each of the 16
+// positions. An unbiased generator produces roughly samples/64 occurrences everywhere, so this can be used in CI to
+// spot skew, e.g. in the combined 16th character
+func DistributionTest(samples int) UIDDistribution {
+	d := UIDDistribution{Samples: samples}
+
+	var uid UID
+	for i := 0; i < samples; i++ {
+		NewUID(&uid)
+		for pos := 0; pos < 16; pos++ {
+			d.Counts[pos][randCharIndex[uid[pos]]]++
+		}
+	}
+
+	return d
+}
+
+// Number of occurrences every character is expected to have at each position
+func (d UIDDistribution) Expected() float64 {
+	return float64(d.Samples) / 64
+}
+
+// Largest relative deviation from 'Expected' across all positions and characters, e.g. 0.05 for 5%
+func (d UIDDistribution) MaxDeviation() float64 {
+	expected := d.Expected()
+	if expected == 0 {
+		return 0
+	}
+
+	var res float64
+	for pos := 0; pos < 16; pos++ {
+		for _, count := range d.Counts[pos] {
+			res = max(res, math.Abs(float64(count)-expected)/expected)
+		}
+	}
+	return res
+}
+
+// Pearson's chi-squared statistic for a single position. With 63 degrees of freedom a uniform distribution
+// averages around 63, values far above ~100 indicate bias
+func (d UIDDistribution) ChiSquared(pos int) float64 {
+	expected := d.Expected()
+	if expected == 0 {
+		return 0
+	}
+
+	var res float64
+	for _, count := range d.Counts[pos] {
+		diff := float64(count) - expected
+		res += diff * diff / expected
+	}
+	return res
+}
+
+// Number of distinct UIDs 'NewUID' can produce: 16 characters with 6 bits each, the 16th one assembled from the
+// 2 bits each random number has left over, so 2^96 in total
+const uidKeyspace = 1 << 96
+
+// Approximate probability that at least one collision occurred among generated UIDs, using the birthday
+// approximation 1 - e^(-n(n-1)/2N). Computed in floating point, so it can't overflow for any n, and with
+// math.Expm1 so tiny probabilities don't get rounded down to 0. Useful to decide when to rotate a keyspace
+func CollisionProbability(generated uint64) float64 {
+	n := float64(generated)
+	return -math.Expm1(-n * (n - 1) / (2 * uidKeyspace))
+}
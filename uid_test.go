@@ -0,0 +1,476 @@
+package btils
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"math"
+	"math/rand/v2"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestDeriveUID(t *testing.T) {
+	var a, b, c, d UID
+	DeriveUID([]byte("users"), []byte("Baloo"), &a)
+	DeriveUID([]byte("users"), []byte("Baloo"), &b)
+	DeriveUID([]byte("users"), []byte("Golang"), &c)
+	DeriveUID([]byte("user"), []byte("sBaloo"), &d)
+
+	if a != b {
+		t.Fatalf("expected identical inputs to derive identical UIDs, got %s and %s", a.ToString(), b.ToString())
+	}
+	if a == c || a == d {
+		t.Fatal("expected different inputs to derive different UIDs")
+	}
+	if !a.IsValid() {
+		t.Fatalf("derived UID %s is not valid", a.ToString())
+	}
+
+	// Pinned so the derivation can't change silently between versions
+	if got := a.ToString(); got != "z5IeTxMuFuNptFAQ" {
+		t.Fatalf("unexpected derived UID %s", got)
+	}
+}
+
+func TestGenerateConcurrent(t *testing.T) {
+	const goroutines, perGoroutine = 8, 10000
+
+	results := make([][]UID, goroutines)
+	var wg sync.WaitGroup
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < perGoroutine; j++ {
+				results[i] = append(results[i], Generate())
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	seen := make(map[UID]struct{}, goroutines*perGoroutine)
+	for _, uids := range results {
+		for _, uid := range uids {
+			if !uid.IsValid() {
+				t.Fatalf("generated invalid UID %v", uid)
+			}
+			if _, ok := seen[uid]; ok {
+				t.Fatalf("duplicate UID %s", uid.ToString())
+			}
+			seen[uid] = struct{}{}
+		}
+	}
+}
+
+func BenchmarkGenerate(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		_ = Generate()
+	}
+}
+
+func BenchmarkNewUID(b *testing.B) {
+	var uid UID
+	for i := 0; i < b.N; i++ {
+		NewUID(&uid)
+	}
+}
+
+func BenchmarkNewUIDMathRand(b *testing.B) {
+	SetEntropySource(rand.Uint32)
+	defer SetEntropySource(nil)
+
+	var uid UID
+	for i := 0; i < b.N; i++ {
+		NewUID(&uid)
+	}
+}
+
+func TestSetEntropySource(t *testing.T) {
+	defer SetEntropySource(nil)
+
+	generate := func() UID {
+		r := rand.New(rand.NewPCG(1, 2))
+		SetEntropySource(r.Uint32)
+		return Generate()
+	}
+
+	a, b := generate(), generate()
+	if a != b || !a.IsValid() {
+		t.Fatalf("expected a seeded source to give reproducible UIDs, got %s and %s", a.ToString(), b.ToString())
+	}
+
+	SetEntropySource(nil)
+	if Generate() == a {
+		t.Fatal("expected nil to restore the default source")
+	}
+}
+
+func TestCopyFrom(t *testing.T) {
+	var src, dst UID
+	NewUID(&src)
+	original := src
+
+	dst.CopyFrom(&src)
+	if dst != src {
+		t.Fatalf("expected %s, got %s", src.ToString(), dst.ToString())
+	}
+
+	// Regenerating the source must not change the copy
+	NewUID(&src)
+	if dst != original {
+		t.Fatal("CopyFrom aliases the source")
+	}
+
+	dst.Clear()
+	if dst != (UID{}) || dst.IsValid() {
+		t.Fatalf("expected a zeroed UID, got %v", dst)
+	}
+}
+
+func TestPseudonymize(t *testing.T) {
+	uid := *UIDFromString("AbCdEfGh_-012345")
+	other := *UIDFromString("AbCdEfGh_-012346")
+
+	a := Pseudonymize(uid, []byte("Foo"))
+	if a != Pseudonymize(uid, []byte("Foo")) {
+		t.Fatal("expected the same UID and key to give the same pseudonym")
+	}
+	if !a.IsValid() || a == uid {
+		t.Fatalf("unexpected pseudonym %s", a.ToString())
+	}
+	if a == Pseudonymize(uid, []byte("Baar")) {
+		t.Fatal("expected a different key to give a different pseudonym")
+	}
+	if a == Pseudonymize(other, []byte("Foo")) {
+		t.Fatal("expected different UIDs to give different pseudonyms")
+	}
+}
+
+func TestFold(t *testing.T) {
+	a := *UIDFromString("AbCdEfGh_-012345")
+	b := *UIDFromString("aBcDeFgH_-012345")
+	c := *UIDFromString("aBcDeFgH_-012346")
+
+	if folded := a.Fold(); folded.ToString() != "abcdefgh_-012345" {
+		t.Fatalf("unexpected folded UID %s", folded.ToString())
+	}
+	if !a.EqualFold(b) {
+		t.Fatal("expected mixed-case UIDs to be equal under EqualFold")
+	}
+	if a.EqualFold(c) {
+		t.Fatal("expected different UIDs to not be equal under EqualFold")
+	}
+	if a.ToString() != "AbCdEfGh_-012345" {
+		t.Fatal("Fold must not modify the receiver")
+	}
+}
+
+func TestDistribution(t *testing.T) {
+	d := DistributionTest(64 * 2000)
+
+	for pos := 0; pos < 16; pos++ {
+		total := 0
+		for _, count := range d.Counts[pos] {
+			total += count
+		}
+		if total != d.Samples {
+			t.Fatalf("position %d counted %d characters, expected %d", pos, total, d.Samples)
+		}
+
+		if chi := d.ChiSquared(pos); chi > 130 {
+			t.Fatalf("position %d looks biased, chi-squared %.2f", pos, chi)
+		}
+	}
+
+	if dev := d.MaxDeviation(); dev > 0.15 {
+		t.Fatalf("max deviation %.2f%% exceeds tolerance", dev*100)
+	}
+}
+
+func TestCollisionProbability(t *testing.T) {
+	// The thresholds documented on UID
+	if p := CollisionProbability(129_209_288_033_988); math.Abs(p-0.1) > 1e-6 {
+		t.Fatalf("expected 10%%, got %f", p)
+	}
+	if p := CollisionProbability(331_411_458_666_437); math.Abs(p-0.5) > 1e-6 {
+		t.Fatalf("expected 50%%, got %f", p)
+	}
+
+	if p := CollisionProbability(0); p != 0 {
+		t.Fatalf("expected 0 for no UIDs, got %g", p)
+	}
+	if p := CollisionProbability(1_000_000); p <= 0 || p > 1e-17 {
+		t.Fatalf("expected a tiny but non-zero probability, got %g", p)
+	}
+	if p := CollisionProbability(math.MaxUint64); p != 1 {
+		t.Fatalf("expected a certain collision, got %g", p)
+	}
+}
+
+func TestCompareUIDStrings(t *testing.T) {
+	a := "AAAAAAAAAAAAAAAA"
+	b := "AAAAAAAAAAAAAAAB"
+
+	if cmp, err := CompareUIDStrings(a, b); err != nil || cmp != -1 {
+		t.Fatalf("expected -1, got %d %v", cmp, err)
+	}
+	if cmp, err := CompareUIDStrings(b, a); err != nil || cmp != 1 {
+		t.Fatalf("expected 1, got %d %v", cmp, err)
+	}
+	if cmp, err := CompareUIDStrings(a, a); err != nil || cmp != 0 {
+		t.Fatalf("expected 0, got %d %v", cmp, err)
+	}
+
+	for _, malformed := range []string{"", "short", "AAAAAAAAAAAAAAAAA", "AAAAAAAAAAAAAAA!"} {
+		if _, err := CompareUIDStrings(a, malformed); err != ErrInvalidUID {
+			t.Fatalf("expected ErrInvalidUID for %q, got %v", malformed, err)
+		}
+		if EqualUIDStrings(malformed, malformed) {
+			t.Fatalf("expected malformed %q to never be equal", malformed)
+		}
+	}
+
+	if !EqualUIDStrings(a, a) || EqualUIDStrings(a, b) {
+		t.Fatal("unexpected EqualUIDStrings result")
+	}
+}
+
+func TestHasPrefixInRange(t *testing.T) {
+	uid := *UIDFromString("AbCdEfGh_-012345")
+
+	for prefix, expected := range map[string]bool{
+		"":                  true,
+		"AbC":               true,
+		"AbCdEfGh_-012345":  true,
+		"abc":               false,
+		"AbCdEfGh_-012346":  false,
+		"AbCdEfGh_-0123456": false,
+	} {
+		if uid.HasPrefix(prefix) != expected {
+			t.Fatalf("expected HasPrefix(%q) to be %v", prefix, expected)
+		}
+	}
+
+	lo := *UIDFromString("AbCdEfGh_-012345")
+	hi := *UIDFromString("AbCdEfGh_-012347")
+	mid := *UIDFromString("AbCdEfGh_-012346")
+
+	if !lo.InRange(lo, hi) {
+		t.Fatal("expected lo to be inclusive")
+	}
+	if hi.InRange(lo, hi) {
+		t.Fatal("expected hi to be exclusive")
+	}
+	if !mid.InRange(lo, hi) || mid.InRange(hi, lo) {
+		t.Fatal("unexpected result for a UID between lo and hi")
+	}
+	if lo.InRange(lo, lo) {
+		t.Fatal("expected an empty range to contain nothing")
+	}
+}
+
+func TestLogValue(t *testing.T) {
+	uid := *UIDFromString("AbCdEfGh_-012345")
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	logger.Info("Foo", "uid", uid, UIDAttr("other", uid))
+
+	if count := strings.Count(buf.String(), `"AbCdEfGh_-012345"`); count != 2 {
+		t.Fatalf("expected the UID to be logged as a string twice, got %s", buf.String())
+	}
+}
+
+func TestReadWriteUIDAt(t *testing.T) {
+	uid := *UIDFromString("AbCdEfGh_-012345")
+	record := make([]byte, 40)
+
+	for _, offset := range []int{0, 7, 24} {
+		if err := WriteUIDAt(record, offset, &uid); err != nil {
+			t.Fatalf("offset %d: %v", offset, err)
+		}
+
+		read, err := ReadUIDAt(record, offset)
+		if err != nil || *read != uid {
+			t.Fatalf("offset %d: expected %s, got %v, %v", offset, uid.ToString(), read, err)
+		}
+	}
+
+	// Reads alias the record
+	read, _ := ReadUIDAt(record, 24)
+	record[24] = 'X'
+	if read[0] != 'X' {
+		t.Fatal("expected ReadUIDAt to not copy")
+	}
+
+	for _, offset := range []int{-1, 25, 40, 1000} {
+		if _, err := ReadUIDAt(record, offset); !errors.Is(err, ErrUIDOutOfBounds) {
+			t.Fatalf("offset %d: expected ErrUIDOutOfBounds, got %v", offset, err)
+		}
+		if err := WriteUIDAt(record, offset, &uid); !errors.Is(err, ErrUIDOutOfBounds) {
+			t.Fatalf("offset %d: expected ErrUIDOutOfBounds, got %v", offset, err)
+		}
+	}
+	if _, err := ReadUIDAt(nil, 0); !errors.Is(err, ErrUIDOutOfBounds) {
+		t.Fatalf("expected ErrUIDOutOfBounds for an empty slice, got %v", err)
+	}
+}
+
+func TestRedact(t *testing.T) {
+	uid := *UIDFromString("abcdefghijklmnyz")
+
+	if got := uid.Redact(); got != "abcd**********yz" {
+		t.Fatalf("unexpected redaction %s", got)
+	}
+	if got := uid.RedactN(2, 3, '#'); got != "ab###########nyz" {
+		t.Fatalf("unexpected redaction %s", got)
+	}
+	if got := uid.RedactN(10, 10, '*'); got != "abcdefghijklmnyz" {
+		t.Fatalf("expected oversized lengths to be clamped to 16, got %s", got)
+	}
+	if got := uid.RedactN(-1, 0, '*'); got != "****************" {
+		t.Fatalf("expected negative lengths to count as 0, got %s", got)
+	}
+}
+
+func TestUint128(t *testing.T) {
+	var uid UID
+	for i := 0; i < 100; i++ {
+		NewUID(&uid)
+
+		hi, lo := uid.Uint128()
+		if *FromUint128(hi, lo) != uid {
+			t.Fatalf("round trip changed %s", uid.ToString())
+		}
+	}
+
+	hi, lo := UIDFromString("AAAAAAAABBBBBBBB").Uint128()
+	if hi != 0x4141414141414141 || lo != 0x4242424242424242 {
+		t.Fatalf("unexpected words %x %x", hi, lo)
+	}
+	if back, _ := FromUint128(0x4242424242424242, 0x4141414141414141).Uint128(); back != 0x4242424242424242 {
+		t.Fatalf("unexpected round trip %x", back)
+	}
+}
+
+func TestUIDSeq(t *testing.T) {
+	seen := map[UID]bool{}
+	for uid := range UIDSeq(100) {
+		if !uid.IsValid() {
+			t.Fatal("generated an invalid UID")
+		}
+		seen[uid] = true
+	}
+	if len(seen) != 100 {
+		t.Fatalf("expected 100 distinct UIDs, got %d", len(seen))
+	}
+
+	n := 0
+	for range UIDSeq(100) {
+		n++
+		if n == 10 {
+			break
+		}
+	}
+	if n != 10 {
+		t.Fatalf("expected to stop after 10, got %d", n)
+	}
+
+	n = 0
+	for range UIDSeqInfinite() {
+		n++
+		if n == 1000 {
+			break
+		}
+	}
+	if n != 1000 {
+		t.Fatalf("expected to stop after 1000, got %d", n)
+	}
+}
+
+func TestChecksummedUID(t *testing.T) {
+	var uid UID
+	for i := 0; i < 100; i++ {
+		NewChecksummedUID(&uid)
+		if !uid.IsValid() || !ValidateChecksum(uid) {
+			t.Fatalf("expected %s to be valid", uid.ToString())
+		}
+
+		for pos := 0; pos < 16; pos++ {
+			for c := 0; c < 256; c++ {
+				if byte(c) == uid[pos] {
+					continue
+				}
+
+				corrupted := uid
+				corrupted[pos] = byte(c)
+				if ValidateChecksum(corrupted) {
+					t.Fatalf("expected replacing position %d of %s with %q to be detected", pos, uid.ToString(), c)
+				}
+			}
+		}
+	}
+}
+
+func TestDedupUIDSeq(t *testing.T) {
+	a, b, c := *UIDFromString("Foo0000000000000"), *UIDFromString("Baar000000000000"), *UIDFromString("Baloo00000000000")
+
+	var res []UID
+	for uid := range DedupUIDSeq(SeqFromSlice([]UID{a, b, a, c, b, a})) {
+		res = append(res, uid)
+	}
+	if !SliceEqual(res, []UID{a, b, c}) {
+		t.Fatalf("expected later duplicates to be dropped, got %v", res)
+	}
+
+	n := 0
+	for range DedupUIDSeq(UIDSeqInfinite()) {
+		n++
+		if n == 10 {
+			break
+		}
+	}
+	if n != 10 {
+		t.Fatalf("expected to stop after 10, got %d", n)
+	}
+}
+
+func TestPathSegment(t *testing.T) {
+	for _, good := range []string{"abcDEF0123456789", "abcDEF012345678_", "-bcDEF0123456789"} {
+		if s, err := UIDFromString(good).PathSegment(); err != nil || s != good {
+			t.Fatalf("expected %s to pass, got %q %v", good, s, err)
+		}
+	}
+
+	var uid UID
+	for i := 0; i < 100; i++ {
+		NewUID(&uid)
+		if _, err := uid.PathSegment(); err != nil {
+			t.Fatalf("expected generated UID %s to pass, got %v", uid.ToString(), err)
+		}
+	}
+
+	if _, err := UIDFromString("abcDEF01234!6789").PathSegment(); !errors.Is(err, ErrUnsafePathSegment) {
+		t.Fatalf("expected ErrUnsafePathSegment, got %v", err)
+	}
+}
+
+func TestStrictPathSegment(t *testing.T) {
+	if s, err := UIDFromString("abcDEF0123456789").StrictPathSegment(); err != nil || s != "abcDEF0123456789" {
+		t.Fatalf("expected an alphanumeric UID to pass, got %q %v", s, err)
+	}
+
+	for _, bad := range []string{"abcDEF012345678_", "-bcDEF0123456789", "abcDEF01234!6789"} {
+		_, err := UIDFromString(bad).StrictPathSegment()
+		if !errors.Is(err, ErrUnsafePathSegment) {
+			t.Fatalf("expected ErrUnsafePathSegment for %s, got %v", bad, err)
+		}
+	}
+
+	_, err := UIDFromString("abc_EF0123456789").StrictPathSegment()
+	if err == nil || !strings.Contains(err.Error(), `'_'`) {
+		t.Fatalf("expected the error to name the bad character, got %v", err)
+	}
+}